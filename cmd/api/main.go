@@ -1,19 +1,35 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gorm.io/gorm"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 
 	"github.com/botlorien/go-rpa-template/config"
+	"github.com/botlorien/go-rpa-template/internal/bootstrap"
+	"github.com/botlorien/go-rpa-template/internal/queue"
 	"github.com/botlorien/go-rpa-template/internal/robot"
 	"github.com/botlorien/go-rpa-template/internal/repository"
 	transport "github.com/botlorien/go-rpa-template/internal/transport/http" // Alias para não confundir com net/http
 	"github.com/botlorien/go-rpa-template/pkg/logger"
 	"github.com/botlorien/go-rpa-template/pkg/database"
-	"github.com/botlorien/go-rpa-template/pkg/botapp"
+	"github.com/botlorien/go-rpa-template/pkg/buildinfo"
+	"github.com/botlorien/go-rpa-template/pkg/health"
+	"github.com/botlorien/go-rpa-template/pkg/metrics"
 )
 
+// cancelTimeout é quanto esperamos as execuções reagirem ao cancelamento no shutdown
+const cancelTimeout = 15 * time.Second
+
 func main() {
 	// 1. Configuração e Logger
 	cfg, err := config.Load()
@@ -31,51 +47,148 @@ func main() {
 	// Middleware de log simples (opcional, já que temos log no handler)
 	r.Use(gin.Logger())
 
-	botConfig := botapp.Config{
-		APIURL:   cfg.BotAppURL,
-		User:     cfg.BotAppUser,
-		Password: cfg.BotAppPass,
+	// 3. Infra: Banco de Dados
+	dbConn, err := bootstrap.OpenDatabase(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Erro no banco")
+	}
+	if err := bootstrap.PrepareDatabase(cfg, dbConn); err != nil {
+		log.Fatal().Err(err).Msg("Erro ao preparar o schema do banco")
 	}
 
-	// 3. Inicializa o Client injetando a config
-	app, err := botapp.NewClient(botConfig)
-	if err != nil {
-		log.Warn().Msg("BotApp API não configurada. Rodando sem logs remotos.")
-		app = nil // O código deve tratar app == nil
-	} else {
-		// 2. Registra o Bot (set_bot do Python)
-		err = app.SetBot("Meu Bot Go", "Descrição do bot", "1.0.0", "TI")
-		if err != nil {
-			log.Error().Err(err).Msg("Falha ao registrar bot na dashboard")
-			os.Exit(1)
-		}
+	// 4. Camada Repository
+	relatorioRepo := repository.NewRelatorioRepository(dbConn)
+	executionRepo := repository.NewExecutionRepository(dbConn)
+
+	// 5. Inicializa o Scraper (Singleton)
+	// O browser é fechado explicitamente no shutdown (defer não roda no SIGTERM)
+	scraperSession := robot.NewSession(cfg.UseRod, cfg.RodHeadless, cfg.PathDownload)
+
+	// 6. Monta os robôs deste binário (cada um se registra no BotApp com os próprios metadados)
+	deps := bootstrap.Deps{
+		Config:     cfg,
+		Session:    scraperSession,
+		Relatorios: relatorioRepo,
+		Executions: executionRepo,
 	}
-	// 4. Infra: Banco de Dados
-	dbConn, err := database.NewConnection(cfg.DBDriver, cfg.DBDSN)
+	robots, err := bootstrap.NewRegistry(deps)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Erro no banco")
+		log.Error().Err(err).Msg("Falha ao registrar robôs")
+		os.Exit(1)
 	}
 
-	// 5. Camada Repository
-	relatorioRepo := repository.NewRelatorioRepository(dbConn)
+	// 7. Fila de execuções e checks de prontidão (/health/ready)
+	runQueue := queue.New(cfg.MaxConcurrentRuns, cfg.MaxQueuedRuns)
 
-	// 6. Inicializa o Scraper (Singleton)
-   scraperSession := robot.NewSession(cfg.UseRod, cfg.RodHeadless, cfg.PathDownload)
-    
-    // IMPORTANTE: Fecha o browser quando a API cair
-    defer scraperSession.Close()
+	checker := health.NewChecker(cfg.HealthTimeout)
+	checker.Register("database", func(ctx context.Context) error {
+		return database.Ping(ctx, dbConn)
+	})
+	checker.Register("browser", scraperSession.Ping)
+	checker.Register("disk", health.DiskSpace(cfg.PathDownload, cfg.MinFreeDiskMB))
+	checker.Register("queue", func(ctx context.Context) error {
+		if runQueue.Saturated() {
+			return fmt.Errorf("fila saturada: %+v", runQueue.Stats())
+		}
+		return nil
+	})
+	if cfg.BotAppURL != "" {
+		// Pinga pelo Client que os robôs já usam (mesma sessão/JWT).
+		// Dashboard fora do ar não impede o robô de rodar: só degrada
+		checker.RegisterOptional("botapp", robots.BotApp.Ping)
+	}
 
-    // 7. Injeta no Service
-    robotService := robot.NewService(scraperSession, relatorioRepo, app)
+	// Gauges lidos sob demanda a cada scrape do /metrics
+	metrics.RegisterGaugeFunc("queue_running", "Execuções rodando agora.", func() float64 {
+		return float64(runQueue.Stats().Running)
+	})
+	metrics.RegisterGaugeFunc("queue_waiting", "Execuções aguardando slot na fila.", func() float64 {
+		return float64(runQueue.Stats().Waiting)
+	})
+	metrics.RegisterGaugeFunc("queue_capacity", "Execuções simultâneas permitidas.", func() float64 {
+		return float64(runQueue.Stats().Capacity)
+	})
+	metrics.RegisterGaugeFunc("browser_pages_open", "Abas abertas no browser Rod.", func() float64 {
+		return float64(scraperSession.PagesOpen())
+	})
 
-	// 8. Criamos o Handler HTTP e injetamos o Robô nele
-	httpHandler := transport.NewHandler(robotService)
+	// 8. Criamos o Handler HTTP e injetamos os Robôs nele
+	httpHandler := transport.NewHandler(robots, runQueue, checker, bootstrap.NewRollback(deps), relatorioRepo, cfg.PathReports)
 
 	// 9. O Handler registra suas próprias rotas no servidor
 	httpHandler.RegisterRoutes(r)
 
 	// ---------------------------------------------------------
 
-	log.Info().Str("port", cfg.AppPort).Msg("Servidor API iniciado")
-	r.Run(":" + cfg.AppPort)
+	// 10. Sobe o servidor e espera SIGINT/SIGTERM (Kubernetes manda SIGTERM no rollout)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{
+		Addr:    ":" + cfg.AppPort,
+		Handler: r,
+	}
+
+	// Limpeza periódica (retenção de downloads, relatórios e linhas antigas): só com MAINTENANCE_INTERVAL > 0
+	if cfg.MaintenanceInterval > 0 {
+		retention, err := bootstrap.NewRetention(cfg, dbConn)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Política de retenção inválida")
+		}
+		go retention.Schedule(ctx, cfg.MaintenanceInterval)
+	}
+
+	go func() {
+		build := buildinfo.Get()
+		log.Info().Str("port", cfg.AppPort).Str("version", build.FullVersion()).Str("commit", build.Commit).Msg("Servidor API iniciado")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal().Err(err).Msg("Falha no servidor HTTP")
+		}
+	}()
+
+	<-ctx.Done()
+	stop() // Um segundo sinal derruba o processo na hora
+	log.Info().Dur("grace_period", cfg.ShutdownGracePeriod).Msg("Sinal recebido, iniciando shutdown")
+
+	shutdown(srv, runQueue, scraperSession, dbConn, cfg.ShutdownGracePeriod)
+	log.Info().Msg("Servidor API finalizado")
+}
+
+// shutdown encerra a API na ordem certa:
+// para de aceitar execuções, espera as em andamento (ou cancela após o grace period),
+// e só então fecha o browser e o pool do banco.
+func shutdown(srv *http.Server, runQueue *queue.Queue, session *robot.Session, db *gorm.DB, grace time.Duration) {
+	// Novas execuções passam a receber 503 e o /health/ready reporta a fila como fechada
+	runQueue.Close()
+
+	// Fecha o listener em paralelo; handlers em andamento terminam quando suas execuções terminarem
+	srvDone := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), grace+cancelTimeout)
+		defer cancel()
+		srvDone <- srv.Shutdown(ctx)
+	}()
+
+	graceCtx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	if err := runQueue.Drain(graceCtx); err != nil {
+		log.Warn().Interface("fila", runQueue.Stats()).Msg("Grace period esgotado, cancelando execuções em andamento")
+		runQueue.Cancel()
+
+		// Dá tempo das execuções canceladas gravarem o status "interrupted" no banco e no BotApp
+		waitCtx, cancelWait := context.WithTimeout(context.Background(), cancelTimeout)
+		defer cancelWait()
+		if err := runQueue.Drain(waitCtx); err != nil {
+			log.Error().Msg("Execuções não finalizaram após o cancelamento")
+		}
+	}
+
+	if err := <-srvDone; err != nil {
+		log.Error().Err(err).Msg("Erro ao encerrar servidor HTTP")
+	}
+
+	session.Close()
+	if err := database.Close(db); err != nil {
+		log.Error().Err(err).Msg("Erro ao fechar conexões do banco")
+	}
 }
@@ -1,14 +1,19 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"os"
+	"os/signal"
+	"os/user"
+	"syscall"
 
 	"github.com/botlorien/go-rpa-template/config"
+	"github.com/botlorien/go-rpa-template/internal/bootstrap"
 	"github.com/botlorien/go-rpa-template/internal/robot"
 	"github.com/botlorien/go-rpa-template/internal/repository"
 	"github.com/botlorien/go-rpa-template/pkg/botapp"
 	"github.com/botlorien/go-rpa-template/pkg/logger"
-	"github.com/botlorien/go-rpa-template/pkg/database"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper" // Importante: Viper mantém o estado do config carregado
 )
@@ -23,39 +28,49 @@ func main() {
 	// 2. Setup do Logger
 	logger.Setup(cfg.LogLevel, cfg.Env)
 
-	log.Info().Msg("Iniciando Worker de RPA via CLI...")
-
-	// 3. Prepara a config específica do BotApp
-	botConfig := botapp.Config{
-		APIURL:   cfg.BotAppURL,
-		User:     cfg.BotAppUser,
-		Password: cfg.BotAppPass,
-	}
-
-	// 4. Inicializa o Client injetando a config
-	app, err := botapp.NewClient(botConfig)
-	if err != nil {
-		log.Warn().Msg("BotApp API não configurada. Rodando sem logs remotos.")
-		app = nil // O código deve tratar app == nil
-	} else {
-		// 5. Registra o Bot (set_bot do Python)
-		err = app.SetBot("Meu Bot Go", "Descrição do bot", "1.0.0", "TI")
-		if err != nil {
-			log.Error().Err(err).Msg("Falha ao registrar bot na dashboard")
-			os.Exit(1)
+	// Subcomandos de manutenção:
+	//   worker migrate up|down|status [-dry-run]
+	//   worker rollback -run <run_id> [-hard] [-force]
+	//   worker purge [-dry-run]
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			runMigrate(cfg, os.Args[2:])
+			return
+		case "rollback":
+			runRollback(cfg, os.Args[2:])
+			return
+		case "purge":
+			runPurge(cfg, os.Args[2:])
+			return
 		}
 	}
 
-	// 6. Infra: Banco de Dados
-	dbConn, err := database.NewConnection(cfg.DBDriver, cfg.DBDSN)
+	// 3. Qual robô rodar: flag -robot ou variável RPA_ROBOT (vazio = robô padrão)
+	robotName := flag.String("robot", viper.GetString("RPA_ROBOT"), "nome do robô a executar")
+	// Origem na dashboard: o agendador (cron, GitLab CI) passa -trigger=scheduler
+	triggerSource := flag.String("trigger", viper.GetString("RPA_TRIGGER"), "origem da execução: cli, scheduler ou webhook")
+	requestedBy := flag.String("requested-by", viper.GetString("RPA_REQUESTED_BY"), "quem pediu a execução (padrão: usuário do sistema)")
+	// Filial dona dos dados: credenciais/params vêm de TENANT_<ID>_AUTH_* / TENANT_<ID>_PARAM_*
+	tenant := flag.String("tenant", viper.GetString("RPA_TENANT"), "tenant (filial) da execução")
+	flag.Parse()
+
+	log.Info().Msg("Iniciando Worker de RPA via CLI...")
+
+	// 4. Infra: Banco de Dados
+	dbConn, err := bootstrap.OpenDatabase(cfg)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Erro no banco")
 	}
+	if err := bootstrap.PrepareDatabase(cfg, dbConn); err != nil {
+		log.Fatal().Err(err).Msg("Erro ao preparar o schema do banco")
+	}
 
-	// 7. Camada Repository
+	// 5. Camada Repository
 	relatorioRepo := repository.NewRelatorioRepository(dbConn)
+	executionRepo := repository.NewExecutionRepository(dbConn)
 
-	// 8. Preparar o Input
+	// 6. Preparar o Input
 	// Mapeamos as variáveis de ambiente para a Struct de entrada do Robô.
 	// O Viper pega tanto do .env quanto das vars do Sistema Operacional.
 	input := robot.ExecutionInput{
@@ -69,39 +84,59 @@ func main() {
 			"filtro_id":   viper.GetString("RPA_FILTRO_ID"),
 			"baixar_pdf":  viper.GetBool("RPA_BAIXAR_PDF"), // Viper converte tipos!
 		},
+		Tenant: *tenant,
 	}
 
-	// 9. Inicializar Infraestrutura (Browser/HTTP)
+	// 7. Inicializar Infraestrutura (Browser/HTTP)
 	scraperSession := robot.NewSession(cfg.UseRod, cfg.RodHeadless, cfg.PathDownload)
 	defer scraperSession.Close()
 
-	// 10. Executar Robô com os Inputs
-	robotService := robot.NewService(scraperSession, relatorioRepo, app)
-	
-	// Passamos o input criado acima
-	// 11. Define a tarefa que será executada (Sua lógica de negócio)
-	// Isso seria o conteúdo da função def minha_tarefa():
-	minhaTarefa := func() (any, error) {
-		// Chama seu Service aqui dentro
-		return robotService.Execute(input)
+	// 8. Monta os robôs (cada um se registra no BotApp) e escolhe o pedido
+	robots, err := bootstrap.NewRegistry(bootstrap.Deps{
+		Config:     cfg,
+		Session:    scraperSession,
+		Relatorios: relatorioRepo,
+		Executions: executionRepo,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Falha ao registrar robôs")
+		os.Exit(1)
 	}
 
-	// 12. Executa a tarefa "Envelopada" 
-	var resultado any
-	
-	if app != nil {
-		// Se a API estiver configurada, roda via RunTask (com logs na dashboard)
-		// "Extrair Dados SSW" -> Nome que vai aparecer na Dash
-		resultado, err = app.RunTask(
-			"Execução Geral", 
-			"Executa pipeline completa", 
-			minhaTarefa,
-		)
-	} else {
-		// Fallback: roda local sem logs na dashboard
-		resultado, err = minhaTarefa()
+	selected, ok := robots.Default()
+	if *robotName != "" {
+		selected, ok = robots.Get(*robotName)
+	}
+	if !ok {
+		log.Fatal().Str("robot", *robotName).Strs("disponiveis", robots.Names()).Msg("Robô não encontrado")
+	}
+
+	// Valida o tenant e completa o input com a config dele (o que veio do RPA_* tem prioridade)
+	if err := robots.Tenants.Resolve(&input); err != nil {
+		log.Fatal().Err(err).Str("tenant", input.Tenant).Msg("Tenant inválido")
+	}
+
+	trigger := botapp.Trigger{Source: *triggerSource, Identity: *requestedBy}
+	if trigger.Source == "" {
+		trigger.Source = botapp.TriggerCLI
+	}
+	if !botapp.ValidTrigger(trigger.Source) {
+		log.Fatal().Str("trigger", trigger.Source).Msg("Origem de execução inválida")
 	}
+	if trigger.Identity == "" {
+		if u, err := user.Current(); err == nil {
+			trigger.Identity = u.Username
+		}
+	}
+
+	// Ctrl+C / SIGTERM cancela a execução e ela é registrada como "interrupted"
+	ctx, stop := signal.NotifyContext(botapp.WithTrigger(context.Background(), trigger), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
+	// 9. Executa o Robô com os Inputs.
+	// O próprio robô envelopa a pipeline numa task da dashboard (quando o BotApp está configurado).
+	log.Info().Str("robot", selected.Metadata().Name).Str("tenant", input.Tenant).Msg("Executando robô")
+	resultado, err := selected.Execute(ctx, input)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Falha crítica na execução do RPA")
 	}
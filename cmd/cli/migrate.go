@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/botlorien/go-rpa-template/config"
+	"github.com/botlorien/go-rpa-template/internal/bootstrap"
+	"github.com/botlorien/go-rpa-template/pkg/database"
+)
+
+const migrateUsage = `Uso: worker migrate <up|down|status> [flags]
+
+  up       aplica as migrações pendentes
+  down     reverte as últimas migrações (-steps, padrão 1)
+  status   lista migrações aplicadas e pendentes
+
+Flags:
+`
+
+// runMigrate executa o subcomando "migrate". Não sobe browser nem registra robôs.
+func runMigrate(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "só imprime o SQL que seria executado")
+	steps := fs.Int("steps", 1, "quantas migrações reverter (down)")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		fs.PrintDefaults()
+	}
+
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	command := args[0]
+	fs.Parse(args[1:])
+
+	dbConn, err := bootstrap.OpenDatabase(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Erro no banco")
+	}
+	defer database.Close(dbConn)
+
+	migrator, err := bootstrap.NewMigrator(dbConn)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Lista de migrações inválida")
+	}
+	migrator.DryRun = *dryRun
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Falha ao aplicar migrações")
+		}
+		if len(applied) == 0 {
+			fmt.Println("Nenhuma migração pendente.")
+		}
+	case "down":
+		if _, err := migrator.Down(ctx, *steps); err != nil {
+			log.Fatal().Err(err).Msg("Falha ao reverter migrações")
+		}
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Falha ao ler status das migrações")
+		}
+		for _, st := range status {
+			state, at := "pendente", ""
+			if st.Applied {
+				state, at = "aplicada", st.AppliedAt.Format("02/01/2006 15:04:05")
+			}
+			if st.Unknown {
+				state = "aplicada (fora do código)"
+			}
+			fmt.Printf("%6d  %-30s  %-26s %s\n", st.Version, st.Name, state, at)
+		}
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/botlorien/go-rpa-template/config"
+	"github.com/botlorien/go-rpa-template/internal/bootstrap"
+	"github.com/botlorien/go-rpa-template/pkg/database"
+)
+
+const purgeUsage = `Uso: worker purge [-dry-run]
+
+  Aplica a política de retenção (RETENTION_*): apaga downloads e relatórios antigos,
+  linhas do banco mais velhas que o configurado por tabela e linhas soft-deleted antigas.
+  Com -dry-run só mostra o que seria removido.
+
+Flags:
+`
+
+// runPurge executa o subcomando "purge". Não sobe browser nem registra robôs.
+func runPurge(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("purge", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "só mostra o que seria removido, sem apagar nada")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, purgeUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	dbConn, err := bootstrap.OpenDatabase(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Erro no banco")
+	}
+	defer database.Close(dbConn)
+	if err := bootstrap.PrepareDatabase(cfg, dbConn); err != nil {
+		log.Fatal().Err(err).Msg("Erro ao preparar o schema do banco")
+	}
+
+	retention, err := bootstrap.NewRetention(cfg, dbConn)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Política de retenção inválida")
+	}
+	report, runErr := retention.Run(context.Background(), *dryRun)
+
+	files, rows := "arquivos removidos", "linhas removidas"
+	if *dryRun {
+		files, rows = "arquivos seriam removidos", "linhas seriam removidas"
+	}
+	for _, d := range report.Dirs {
+		fmt.Printf("📁 %-40s  %6d %s (%.1f MB)  (anteriores a %s)\n",
+			d.Dir, d.Files, files, float64(d.Bytes)/(1<<20), d.Before.Format("02/01/2006"))
+	}
+	for _, t := range report.Tables {
+		fmt.Printf("🗄️  %-20s %-13s  %6d %s  (anteriores a %s)\n",
+			t.Table, t.Rule, t.Rows, rows, t.Before.Format("02/01/2006"))
+	}
+	if len(report.Dirs) == 0 && len(report.Tables) == 0 {
+		fmt.Println("Nenhuma política de retenção ligada (RETENTION_*)")
+	}
+
+	if runErr != nil {
+		database.Close(dbConn) // log.Fatal não roda o defer
+		log.Fatal().Err(runErr).Msg("Limpeza terminou com erros")
+	}
+	if *dryRun {
+		fmt.Println("🔍 Dry-run: nada foi apagado")
+		return
+	}
+	fmt.Printf("✅ Limpeza concluída: %d arquivos e %d linhas removidas\n", report.TotalFiles(), report.TotalRows())
+}
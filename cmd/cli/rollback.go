@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/botlorien/go-rpa-template/config"
+	"github.com/botlorien/go-rpa-template/internal/bootstrap"
+	"github.com/botlorien/go-rpa-template/internal/repository"
+	"github.com/botlorien/go-rpa-template/internal/robot"
+	"github.com/botlorien/go-rpa-template/pkg/botapp"
+	"github.com/botlorien/go-rpa-template/pkg/database"
+)
+
+const rollbackUsage = `Uso: worker rollback -run <run_id> [-tenant <id>] [flags]
+
+  Desfaz os dados carregados por uma execução: apaga as linhas que ela inseriu
+  e devolve as que ela sobrescreveu. O rollback fica registrado no histórico.
+
+Flags:
+`
+
+// runRollback executa o subcomando "rollback". Não sobe browser nem registra robôs.
+func runRollback(cfg *config.Config, args []string) {
+	fs := flag.NewFlagSet("rollback", flag.ExitOnError)
+	runID := fs.String("run", "", "run_id da execução a desfazer")
+	hard := fs.Bool("hard", false, "apaga de verdade as linhas inseridas (padrão: soft delete)")
+	force := fs.Bool("force", false, "desfaz mesmo com a execução ainda como running (processo morreu)")
+	tenant := fs.String("tenant", "", "tenant (filial) da execução; vazio = tenant padrão")
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, rollbackUsage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *runID == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	dbConn, err := bootstrap.OpenDatabase(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Erro no banco")
+	}
+	defer database.Close(dbConn)
+	if err := bootstrap.PrepareDatabase(cfg, dbConn); err != nil {
+		log.Fatal().Err(err).Msg("Erro ao preparar o schema do banco")
+	}
+
+	req := repository.RollbackRequest{
+		RunID:         *runID,
+		Hard:          *hard,
+		Force:         *force,
+		TriggerSource: botapp.TriggerCLI,
+	}
+	if u, err := user.Current(); err == nil {
+		req.RequestedBy = u.Username
+	}
+
+	rollback := bootstrap.NewRollback(bootstrap.Deps{
+		Relatorios: repository.NewRelatorioRepository(dbConn),
+		Executions: repository.NewExecutionRepository(dbConn),
+	})
+	// O rollback só enxerga a execução (e as linhas) do tenant dela
+	tenantInput := robot.ExecutionInput{Tenant: *tenant}
+	if err := bootstrap.NewTenants(cfg).Resolve(&tenantInput); err != nil {
+		log.Fatal().Err(err).Msg("Tenant inválido")
+	}
+	ctx := repository.WithTenant(context.Background(), tenantInput.Tenant)
+	report, err := rollback.Run(ctx, req)
+	if err != nil {
+		log.Fatal().Err(err).Str("run_id", *runID).Msg("Falha no rollback")
+	}
+
+	for _, t := range report.Tables {
+		fmt.Printf("%-30s  %6d removidas  %6d restauradas  %6d conflitos  %6d sem snapshot\n", t.Table, t.Deleted, t.Restored, t.Conflicts, t.Kept)
+	}
+	fmt.Printf("✅ Execução %s desfeita (registro do rollback: %s)\n", report.RunID, report.RollbackRunID)
+}
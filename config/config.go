@@ -3,13 +3,16 @@ package config
 import (
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
 	BaseDir   string `mapstructure:"BASE_DIR"`
 	PathDownload   string `mapstructure:"PATH_DOWNLOAD"`
-	pathReports   string `mapstructure:"PATH_REPORTS"`
+	PathReports   string `mapstructure:"PATH_REPORTS"` // Relatórios gerados (exportações CSV/XLSX da API)
 	AppPort  string `mapstructure:"APP_PORT"`
 	TargetURL string `mapstructure:"TARGET_URL"`
 	LogLevel string `mapstructure:"LOG_LEVEL"`
@@ -19,8 +22,45 @@ type Config struct {
 	BotAppURL  string `mapstructure:"BOTAPP_API_URL"`
 	BotAppUser string `mapstructure:"BOTAPP_API_USUARIO"`
 	BotAppPass string `mapstructure:"BOTAPP_API_SENHA"`
-	DBDriver string `mapstructure:"DB_DRIVER"` // postgres, mysql, sqlite
+	BotAppAuthMode      string `mapstructure:"BOTAPP_AUTH_MODE"`       // basic, token, jwt ou none (só mTLS)
+	BotAppToken         string `mapstructure:"BOTAPP_API_TOKEN"`       // Token do DRF
+	BotAppTokenFile     string `mapstructure:"BOTAPP_API_TOKEN_FILE"`  // Token em arquivo (secret montado), relido no 401
+	BotAppJWTTokenURL   string `mapstructure:"BOTAPP_JWT_TOKEN_URL"`   // Padrão: BOTAPP_API_URL/token/
+	BotAppJWTRefreshURL string `mapstructure:"BOTAPP_JWT_REFRESH_URL"` // Padrão: BOTAPP_API_URL/token/refresh/
+	BotAppTLSCert       string `mapstructure:"BOTAPP_TLS_CERT"`        // Certificado cliente (mTLS)
+	BotAppTLSKey        string `mapstructure:"BOTAPP_TLS_KEY"`
+	BotAppTLSCA         string `mapstructure:"BOTAPP_TLS_CA"`          // CA do servidor, se não for pública
+	BotAppOutboxDir string `mapstructure:"BOTAPP_OUTBOX_DIR"` // Logs pendentes quando a dashboard está fora
+	BotAppMaxResultBytes int `mapstructure:"BOTAPP_MAX_RESULT_BYTES"` // Acima disso o result_data é truncado
+	BotAppHeartbeat  time.Duration `mapstructure:"BOTAPP_HEARTBEAT_INTERVAL"` // Intervalo do last_heartbeat das tasks
+	BotAppStaleAfter time.Duration `mapstructure:"BOTAPP_STALE_AFTER"`        // Sem heartbeat por esse tempo = abandonada
+	BotAppStatePoll  time.Duration `mapstructure:"BOTAPP_STATE_POLL_INTERVAL"` // Consulta de pausa/desativação durante a execução
+	BotAppMaxArtifactMB int64 `mapstructure:"BOTAPP_MAX_ARTIFACT_MB"` // Maior arquivo anexado aos logs
+	DBDriver string `mapstructure:"DB_DRIVER"` // postgres, mysql, sqlite, sqlserver, clickhouse
     DBDSN    string `mapstructure:"DB_DSN"`    // Connection String
+	DBMigrateOnStart bool `mapstructure:"DB_MIGRATE_ON_START"` // Aplica as migrações pendentes na subida
+	DBAutoMigrate    bool `mapstructure:"DB_AUTO_MIGRATE"`     // Modo dev: AutoMigrate direto dos models
+	DBMaxOpenConns     int           `mapstructure:"DB_MAX_OPEN_CONNS"`     // Pool: conexões abertas no máximo
+	DBMaxIdleConns     int           `mapstructure:"DB_MAX_IDLE_CONNS"`     // Pool: conexões paradas mantidas
+	DBConnMaxLifetime  time.Duration `mapstructure:"DB_CONN_MAX_LIFETIME"`  // Recicla conexões mais velhas que isso
+	DBConnMaxIdleTime  time.Duration `mapstructure:"DB_CONN_MAX_IDLE_TIME"` // Fecha conexões paradas há mais que isso
+	DBConnectRetries   int           `mapstructure:"DB_CONNECT_RETRIES"`    // Novas tentativas se o banco não responder na subida
+	DBConnectBackoff   time.Duration `mapstructure:"DB_CONNECT_BACKOFF"`    // Espera inicial entre tentativas (dobra a cada falha)
+	DBStatementTimeout time.Duration `mapstructure:"DB_STATEMENT_TIMEOUT"`  // Prazo de cada query (0 = sem prazo)
+	DBSlowQuery        time.Duration `mapstructure:"DB_SLOW_QUERY"`         // Acima disso a query sai como warning
+	DBLogLevel         string        `mapstructure:"DB_LOG_LEVEL"`          // silent, error, warn ou info (todo SQL)
+	DBLogParams        bool          `mapstructure:"DB_LOG_PARAMS"`         // Valores das queries no log (cuidado com dados sensíveis)
+	MaxConcurrentRuns int           `mapstructure:"MAX_CONCURRENT_RUNS"`    // Execuções simultâneas na API
+	MaxQueuedRuns     int           `mapstructure:"MAX_QUEUED_RUNS"`        // Execuções aguardando slot na API
+	HealthTimeout     time.Duration `mapstructure:"HEALTH_CHECK_TIMEOUT"`   // Timeout de cada check do /health/ready
+	MinFreeDiskMB     uint64        `mapstructure:"HEALTH_MIN_FREE_DISK_MB"` // Espaço mínimo livre em PATH_DOWNLOAD
+	ShutdownGracePeriod time.Duration `mapstructure:"SHUTDOWN_GRACE_PERIOD"` // Espera das execuções em andamento no SIGTERM
+	RetentionDownloadsDays   int           `mapstructure:"RETENTION_DOWNLOADS_DAYS"`    // Dias de arquivos em PATH_DOWNLOAD (0 = para sempre)
+	RetentionReportsDays     int           `mapstructure:"RETENTION_REPORTS_DAYS"`      // Dias de arquivos em PATH_REPORTS (0 = para sempre)
+	RetentionTableDays       string        `mapstructure:"RETENTION_TABLE_DAYS"`        // Dias de linhas por tabela: "relatorio_pesos=365,executions=180"
+	RetentionSoftDeletedDays int           `mapstructure:"RETENTION_SOFT_DELETED_DAYS"` // Linhas soft-deleted há mais que isso saem de vez (0 = nunca)
+	MaintenanceInterval      time.Duration `mapstructure:"MAINTENANCE_INTERVAL"`        // Intervalo da limpeza automática na API (0 = desligada)
+	Tenants                  string        `mapstructure:"TENANTS"`                     // Filiais aceitas: "filial_sp,filial_rj" (vazio = tenant opcional)
 }
 
 func Load() (*Config, error) {
@@ -40,12 +80,48 @@ func Load() (*Config, error) {
 	viper.SetDefault("BASE_DIR", rootDir)
 	viper.SetDefault("PATH_DOWNLOAD", pathDownload)
 	viper.SetDefault("PATH_REPORTS", pathReports)
+	viper.SetDefault("BOTAPP_OUTBOX_DIR", filepath.Join(rootDir, ".botapp-outbox"))
+	viper.SetDefault("BOTAPP_MAX_RESULT_BYTES", 64*1024)
+	viper.SetDefault("BOTAPP_AUTH_MODE", "basic")
+	// Sem default o AutomaticEnv do viper não preenche o campo no Unmarshal
+	for _, key := range []string{"BOTAPP_API_TOKEN", "BOTAPP_API_TOKEN_FILE", "BOTAPP_JWT_TOKEN_URL", "BOTAPP_JWT_REFRESH_URL", "BOTAPP_TLS_CERT", "BOTAPP_TLS_KEY", "BOTAPP_TLS_CA"} {
+		viper.SetDefault(key, "")
+	}
+	viper.SetDefault("BOTAPP_HEARTBEAT_INTERVAL", "30s")
+	viper.SetDefault("BOTAPP_STALE_AFTER", "5m")
+	viper.SetDefault("BOTAPP_STATE_POLL_INTERVAL", "15s")
+	viper.SetDefault("BOTAPP_MAX_ARTIFACT_MB", 100)
+
+	viper.SetDefault("DB_MIGRATE_ON_START", true)
+	viper.SetDefault("DB_AUTO_MIGRATE", false)
+	viper.SetDefault("DB_MAX_OPEN_CONNS", 10)
+	viper.SetDefault("DB_MAX_IDLE_CONNS", 5)
+	viper.SetDefault("DB_CONN_MAX_LIFETIME", "30m")
+	viper.SetDefault("DB_CONN_MAX_IDLE_TIME", "5m")
+	viper.SetDefault("DB_CONNECT_RETRIES", 5)
+	viper.SetDefault("DB_CONNECT_BACKOFF", "1s")
+	viper.SetDefault("DB_STATEMENT_TIMEOUT", "60s")
+	viper.SetDefault("DB_SLOW_QUERY", "500ms")
+	viper.SetDefault("DB_LOG_LEVEL", "warn")
+	viper.SetDefault("DB_LOG_PARAMS", false)
 
 	viper.SetDefault("APP_PORT", "8080")
 	viper.SetDefault("LOG_LEVEL", "info")
 	viper.SetDefault("APP_ENV", "local") // Por padrão é modo dev
 	viper.SetDefault("USE_ROD", false)      // Padrão leve
 	viper.SetDefault("ROD_HEADLESS", true)  // Padrão silencioso
+	viper.SetDefault("MAX_CONCURRENT_RUNS", 1) // Session compartilha browser/cookies, então 1 por padrão
+	viper.SetDefault("MAX_QUEUED_RUNS", 10)
+	viper.SetDefault("HEALTH_CHECK_TIMEOUT", "5s")
+	viper.SetDefault("HEALTH_MIN_FREE_DISK_MB", 500)
+	viper.SetDefault("SHUTDOWN_GRACE_PERIOD", "30s")
+	// Retenção apaga dados: tudo desligado até alguém configurar (0 = para sempre)
+	viper.SetDefault("RETENTION_DOWNLOADS_DAYS", 0)
+	viper.SetDefault("RETENTION_REPORTS_DAYS", 0)
+	viper.SetDefault("RETENTION_TABLE_DAYS", "")
+	viper.SetDefault("RETENTION_SOFT_DELETED_DAYS", 0)
+	viper.SetDefault("MAINTENANCE_INTERVAL", "0") // Limpeza automática na API só se pedir
+	viper.SetDefault("TENANTS", "")
 	
 
 	if err := viper.ReadInConfig(); err != nil {
@@ -55,4 +131,63 @@ func Load() (*Config, error) {
 	var cfg Config
 	err = viper.Unmarshal(&cfg)
 	return &cfg, err
-}
\ No newline at end of file
+}
+
+// BotMetadata são os dados do bot na dashboard que podem vir da config em vez do código
+type BotMetadata struct {
+	Description string
+	Department  string
+	Version     string
+}
+
+// BotMetadataFor lê os overrides de um robô: BOT_<ROBO>_<CAMPO> (ex: BOT_RELATORIO_PESO_DEPARTMENT),
+// com fallback em BOT_<CAMPO>, que vale para todos os robôs do binário. Vazio = usa o do código/build.
+func BotMetadataFor(robot string) BotMetadata {
+	prefix := "BOT_" + strings.ToUpper(strings.NewReplacer("-", "_", " ", "_").Replace(robot)) + "_"
+	get := func(field string) string {
+		if v := viper.GetString(prefix + field); v != "" {
+			return v
+		}
+		return viper.GetString("BOT_" + field)
+	}
+	return BotMetadata{
+		Description: get("DESCRIPTION"),
+		Department:  get("DEPARTMENT"),
+		Version:     get("VERSION"),
+	}
+}
+
+// TenantConfig são as credenciais e params de um tenant (filial) vindos da config
+type TenantConfig struct {
+	APIKey string // Chave que quem chama a API precisa mandar para usar este tenant
+	Auth   map[string]string
+	Params map[string]string
+}
+
+// TenantConfigFor lê TENANT_<ID>_API_KEY, TENANT_<ID>_AUTH_<CHAVE> e TENANT_<ID>_PARAM_<CHAVE>
+// (ex: TENANT_FILIAL_SP_AUTH_PASSWORD vira Auth["password"] do tenant filial_sp).
+func TenantConfigFor(tenant string) TenantConfig {
+	prefix := "tenant_" + strings.ToLower(strings.NewReplacer("-", "_", " ", "_").Replace(tenant)) + "_"
+	out := TenantConfig{Auth: map[string]string{}, Params: map[string]string{}}
+
+	// Chaves do .env (o viper devolve em minúsculas) + variáveis de ambiente, que o viper não lista
+	keys := viper.AllKeys()
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		keys = append(keys, strings.ToLower(name))
+	}
+	for _, key := range keys {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+		if rest == "api_key" {
+			out.APIKey = viper.GetString(key)
+		} else if name, ok := strings.CutPrefix(rest, "auth_"); ok {
+			out.Auth[name] = viper.GetString(key)
+		} else if name, ok := strings.CutPrefix(rest, "param_"); ok {
+			out.Params[name] = viper.GetString(key)
+		}
+	}
+	return out
+}
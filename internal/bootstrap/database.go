@@ -0,0 +1,74 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	"github.com/botlorien/go-rpa-template/config"
+	"github.com/botlorien/go-rpa-template/internal/migrations"
+	"github.com/botlorien/go-rpa-template/internal/repository"
+	"github.com/botlorien/go-rpa-template/pkg/database"
+	"github.com/botlorien/go-rpa-template/pkg/migrate"
+)
+
+// OpenDatabase conecta no banco com pool, retry, timeout e log vindos da configuração
+func OpenDatabase(cfg *config.Config) (*gorm.DB, error) {
+	return database.Open(cfg.DBDriver, cfg.DBDSN, database.Options{
+		MaxOpenConns:     cfg.DBMaxOpenConns,
+		MaxIdleConns:     cfg.DBMaxIdleConns,
+		ConnMaxLifetime:  cfg.DBConnMaxLifetime,
+		ConnMaxIdleTime:  cfg.DBConnMaxIdleTime,
+		ConnectRetries:   cfg.DBConnectRetries,
+		ConnectBackoff:   cfg.DBConnectBackoff,
+		StatementTimeout: cfg.DBStatementTimeout,
+		Logger: database.LoggerConfig{
+			Level:         database.ParseLogLevel(cfg.DBLogLevel),
+			SlowThreshold: cfg.DBSlowQuery,
+			LogParams:     cfg.DBLogParams,
+		},
+	})
+}
+
+// NewMigrator monta o Migrator com as migrações do template
+func NewMigrator(db *gorm.DB) (*migrate.Migrator, error) {
+	return migrate.New(db, migrations.All())
+}
+
+// PrepareDatabase deixa o banco pronto na subida da API/CLI: callbacks (linhagem e tenant) e schema.
+// As migrações pendentes rodam com lock (instâncias subindo juntas esperam umas às outras);
+// o AutoMigrate só roda se ligado explicitamente (modo dev).
+func PrepareDatabase(cfg *config.Config, db *gorm.DB) error {
+	// Linhas gravadas durante uma execução saem com o run_id dela (linhagem/rollback)
+	if err := repository.RegisterLineage(db); err != nil {
+		return fmt.Errorf("falha ao registrar callbacks do banco: %w", err)
+	}
+	// Cada tenant (filial) só enxerga e grava as próprias linhas
+	if err := repository.RegisterTenancy(db); err != nil {
+		return fmt.Errorf("falha ao registrar callbacks do banco: %w", err)
+	}
+
+	if cfg.DBMigrateOnStart {
+		migrator, err := NewMigrator(db)
+		if err != nil {
+			return err
+		}
+		applied, err := migrator.Up(context.Background())
+		if err != nil {
+			return fmt.Errorf("falha ao aplicar migrações: %w", err)
+		}
+		if len(applied) > 0 {
+			log.Info().Int("migracoes", len(applied)).Msg("Schema do banco atualizado")
+		}
+	}
+
+	if cfg.DBAutoMigrate {
+		log.Warn().Msg("DB_AUTO_MIGRATE ligado: ajustando tabelas direto dos models (use só em desenvolvimento)")
+		if err := migrations.AutoMigrate(db); err != nil {
+			return fmt.Errorf("falha no AutoMigrate: %w", err)
+		}
+	}
+	return nil
+}
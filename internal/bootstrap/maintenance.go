@@ -0,0 +1,82 @@
+package bootstrap
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/botlorien/go-rpa-template/config"
+	"github.com/botlorien/go-rpa-template/internal/domain"
+	"github.com/botlorien/go-rpa-template/internal/maintenance"
+	"github.com/botlorien/go-rpa-template/internal/repository"
+)
+
+// NewRetention monta a limpeza com a política da config.
+// Para um model novo entrar na retenção, acrescente o alvo aqui (tabela + coluna de data).
+func NewRetention(cfg *config.Config, db *gorm.DB) (*maintenance.Retention, error) {
+	targets := []repository.RetentionTarget{
+		// Pela data de referência: "manter 365 dias" = relatórios do último ano
+		{Table: "relatorio_pesos", Column: "data_processamento", Purger: repository.New[domain.RelatorioPeso](db)},
+		{Table: "executions", Column: "started_at", Purger: repository.New[domain.Execution](db)},
+		// Só os snapshots de execuções já desfeitas ou expurgadas: os outros são o que o rollback devolve
+		{Table: "row_snapshots", Column: "created_at", Purger: repository.NewSnapshotPurger(db)},
+	}
+
+	tableDays, err := parseTableDays(cfg.RetentionTableDays)
+	if err != nil {
+		return nil, err
+	}
+	for table := range tableDays {
+		if !hasTarget(targets, table) {
+			return nil, fmt.Errorf("RETENTION_TABLE_DAYS: tabela sem retenção configurada: %s (use %s)", table, targetNames(targets))
+		}
+	}
+
+	return maintenance.NewRetention(maintenance.Policy{
+		DownloadsDir:    cfg.PathDownload,
+		DownloadsDays:   cfg.RetentionDownloadsDays,
+		ReportsDir:      cfg.PathReports,
+		ReportsDays:     cfg.RetentionReportsDays,
+		TableDays:       tableDays,
+		SoftDeletedDays: cfg.RetentionSoftDeletedDays,
+	}, targets...), nil
+}
+
+// parseTableDays lê "relatorio_pesos=365, executions=180"
+func parseTableDays(raw string) (map[string]int, error) {
+	out := map[string]int{}
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		table, value, ok := strings.Cut(item, "=")
+		days, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil || days < 0 {
+			return nil, fmt.Errorf("RETENTION_TABLE_DAYS inválido em %q (use tabela=dias)", item)
+		}
+		out[strings.TrimSpace(table)] = days
+	}
+	return out, nil
+}
+
+func hasTarget(targets []repository.RetentionTarget, table string) bool {
+	for _, t := range targets {
+		if t.Table == table {
+			return true
+		}
+	}
+	return false
+}
+
+func targetNames(targets []repository.RetentionTarget) string {
+	names := make([]string, len(targets))
+	for i, t := range targets {
+		names[i] = t.Table
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
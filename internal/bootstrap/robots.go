@@ -0,0 +1,149 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/botlorien/go-rpa-template/config"
+	"github.com/botlorien/go-rpa-template/internal/repository"
+	"github.com/botlorien/go-rpa-template/internal/robot"
+	"github.com/botlorien/go-rpa-template/pkg/botapp"
+	"github.com/botlorien/go-rpa-template/pkg/buildinfo"
+)
+
+// Deps agrupa a infraestrutura compartilhada entre os robôs do binário
+type Deps struct {
+	Config     *config.Config
+	Session    *robot.Session
+	Relatorios *repository.RelatorioRepository
+	Executions *repository.ExecutionRepository
+}
+
+// NewRegistry monta todos os robôs deste binário (API e CLI usam a mesma lista).
+// Para adicionar um robô novo: construa-o aqui e chame registry.Register.
+func NewRegistry(d Deps) (*robot.Registry, error) {
+	registry := robot.NewRegistry()
+	registry.Tenants = NewTenants(d.Config)
+
+	// Cada robô tem o próprio Client do BotApp, registrado com os próprios metadados
+	relatorioMeta := ResolveMetadata(robot.RelatorioMetadata)
+	relatorioApp, err := NewBotApp(d.Config, relatorioMeta)
+	if err != nil {
+		return nil, err
+	}
+	registry.BotApp = relatorioApp
+	relatorio := robot.NewService(d.Session, d.Relatorios, d.Executions, relatorioApp)
+	relatorio.Meta = relatorioMeta
+	if err := registry.Register(relatorio); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// NewTenants monta a política de tenants (filiais) com a config: TENANTS e TENANT_<ID>_*
+func NewTenants(cfg *config.Config) *robot.Tenants {
+	var allowed []string
+	for _, t := range strings.Split(cfg.Tenants, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			allowed = append(allowed, t)
+		}
+	}
+	return &robot.Tenants{
+		Allowed: allowed,
+		Settings: func(tenant string) robot.TenantSettings {
+			tc := config.TenantConfigFor(tenant)
+			params := make(map[string]any, len(tc.Params))
+			for k, v := range tc.Params {
+				params[k] = v
+			}
+			return robot.TenantSettings{APIKey: tc.APIKey, Auth: tc.Auth, Params: params}
+		},
+	}
+}
+
+// NewRollback lista as tabelas cujas cargas podem ser desfeitas por execução (rollback -run / DELETE .../data).
+// Repositório novo com coluna run_id entra aqui.
+func NewRollback(d Deps) *repository.Rollback {
+	return repository.NewRollback(d.Executions, d.Relatorios)
+}
+
+// ResolveMetadata completa os metadados declarados no código do robô:
+// a config (BOT_<ROBO>_* / BOT_*) tem prioridade e, sem versão declarada,
+// vale a do build (tag + commit), que é o que está de fato em produção.
+func ResolveMetadata(meta robot.Metadata) robot.Metadata {
+	override := config.BotMetadataFor(meta.Name)
+	if override.Description != "" {
+		meta.Description = override.Description
+	}
+	if override.Department != "" {
+		meta.Department = override.Department
+	}
+
+	build := buildinfo.Get()
+	switch {
+	case override.Version != "":
+		meta.Version = override.Version
+	case meta.Version == "":
+		meta.Version = build.FullVersion()
+	}
+	meta.Commit = build.Commit
+	return meta
+}
+
+// BotAppConfig traduz a config da aplicação para a do Client do BotApp (sem o outbox, que é por robô)
+func BotAppConfig(cfg *config.Config) botapp.Config {
+	return botapp.Config{
+		APIURL:        cfg.BotAppURL,
+		User:          cfg.BotAppUser,
+		Password:      cfg.BotAppPass,
+		AuthMode:      cfg.BotAppAuthMode,
+		Token:         cfg.BotAppToken,
+		TokenFile:     cfg.BotAppTokenFile,
+		JWTTokenURL:   cfg.BotAppJWTTokenURL,
+		JWTRefreshURL: cfg.BotAppJWTRefreshURL,
+		TLSCertFile:   cfg.BotAppTLSCert,
+		TLSKeyFile:    cfg.BotAppTLSKey,
+		TLSCAFile:     cfg.BotAppTLSCA,
+
+		MaxResultBytes: cfg.BotAppMaxResultBytes,
+		// Heartbeat bem menor que o StaleAfter, senão execuções vivas são fechadas como abandonadas
+		HeartbeatInterval: cfg.BotAppHeartbeat,
+		StaleAfter:        cfg.BotAppStaleAfter,
+		StatePollInterval: cfg.BotAppStatePoll,
+		MaxArtifactBytes:  cfg.BotAppMaxArtifactMB << 20,
+	}
+}
+
+// NewBotApp cria o Client do BotApp e registra o robô na dashboard (set_bot do Python).
+// Quando a API não está configurada devolve o Console: o robô roda com logs só no terminal.
+func NewBotApp(cfg *config.Config, meta robot.Metadata) (botapp.Reporter, error) {
+	if cfg.BotAppURL == "" {
+		log.Warn().Str("robot", meta.Name).Msg("BotApp API não configurada. Rodando sem logs remotos.")
+		console := botapp.NewConsole()
+		return console, console.SetBot(meta.Name, meta.Description, meta.Version, meta.Department)
+	}
+
+	botConfig := BotAppConfig(cfg)
+	if cfg.BotAppOutboxDir != "" {
+		// Um outbox por robô: a ordem e os refs de cada Client são independentes
+		botConfig.OutboxDir = filepath.Join(cfg.BotAppOutboxDir, meta.Name)
+	}
+
+	app, err := botapp.NewClient(botConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := app.SetBot(meta.Name, meta.Description, meta.Version, meta.Department); err != nil {
+		return nil, fmt.Errorf("falha ao registrar bot '%s' na dashboard: %w", meta.Name, err)
+	}
+
+	// Reenvia em segundo plano os logs que ficarem pendentes com a dashboard fora
+	go app.RunOutbox(context.Background())
+	return app, nil
+}
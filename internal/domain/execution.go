@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// Status possíveis de uma Execution
+const (
+	ExecutionRunning     = "running"
+	ExecutionCompleted   = "completed"
+	ExecutionFailed      = "failed"
+	ExecutionInterrupted = "interrupted" // Cancelada no shutdown (SIGTERM) antes de terminar
+	ExecutionRolledBack  = "rolled_back" // Dados carregados por ela foram desfeitos (rollback)
+)
+
+// Execution registra cada execução do robô no nosso próprio banco.
+// O RunID também vai nas linhas gravadas pelos repositórios (coluna run_id): dá para saber
+// qual execução carregou cada dado e desfazer uma carga ruim.
+type Execution struct {
+	gorm.Model
+	RunID           string         `gorm:"uniqueIndex;size:36"`
+	TenantID        string         `gorm:"size:64;not null;default:'';index"` // Filial da execução ("" = tenant padrão)
+	Robot           string         `gorm:"index;size:100"`
+	RobotVersion    string         `gorm:"size:100"`
+	TriggerSource   string         `gorm:"size:20"`                   // api, cli, scheduler, webhook
+	TriggerIdentity string         `gorm:"size:191"`                  // Quem pediu (usuário, IP, job)
+	Input           map[string]any `gorm:"type:text;serializer:json"` // Com as credenciais mascaradas
+	Status          string         `gorm:"index;size:20"`
+	StartedAt       time.Time
+	FinishedAt      *time.Time
+	Error           string
+	ResultSummary   map[string]any `gorm:"type:text;serializer:json"` // Mesmo resumo do result_data da dashboard
+	Artifacts       []string       `gorm:"type:text;serializer:json"` // Arquivos declarados com AddOutput
+}
@@ -1,13 +1,21 @@
 package domain
 
 import (
-	"time"
 	"gorm.io/gorm"
+	"time"
 )
 
+// RelatorioPeso tem chave natural (Destino, DataProcessamento) dentro de cada tenant: é o que o
+// Upsert usa para atualizar em vez de duplicar quando o robô roda de novo para o mesmo dia.
+// As tags df ligam as colunas do relatório baixado aos campos (processor.Decode).
+// O relatório do sistema alvo normalmente não traz a data: ela é a data de referência
+// da execução, passada ao SaveBatch.
 type RelatorioPeso struct {
 	gorm.Model
-	Destino          string  `gorm:"index"` // Index ajuda na busca
-	PesoCalculoTotal float64 `gorm:"type:decimal(15,2)"`
-	DataProcessamento time.Time
-}
\ No newline at end of file
+	// Índice próprio além do único: o único começa pelo tenant_id, então o filtro ?destino= da listagem não o usaria
+	Destino           string    `gorm:"size:191;uniqueIndex:idx_relatorio_peso_natural;index" df:"Destino,required"`
+	PesoCalculoTotal  float64   `gorm:"type:decimal(15,2)" df:"Peso Cálculo Total,br_float"`
+	DataProcessamento time.Time `gorm:"uniqueIndex:idx_relatorio_peso_natural" df:"Data Processamento,date,optional"`  // Data de referência (sem a coluna no arquivo, o SaveBatch carimba)
+	RunID             string    `gorm:"size:36;index"`                                                                 // Execução que gravou a linha por último (preenchido pelo repositório)
+	TenantID          string    `gorm:"size:64;not null;default:'';uniqueIndex:idx_relatorio_peso_natural,priority:1"` // Filial dona da linha (preenchido pelo repositório)
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// Operações que geram um RowSnapshot
+const (
+	SnapshotUpdate = "update" // Linha sobrescrita por um Upsert
+	SnapshotDelete = "delete" // Linha apagada por um ReplacePartition
+)
+
+// RowSnapshot guarda como uma linha estava antes de uma execução sobrescrevê-la ou apagá-la.
+// É o que permite o rollback de uma carga ruim devolver os dados antigos.
+// Uma linha tem no máximo um snapshot por execução (o estado de antes da primeira escrita).
+type RowSnapshot struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	RunID     string `gorm:"size:36;uniqueIndex:idx_row_snapshot_run_row"`
+	TenantID  string `gorm:"size:64;not null;default:'';index"`
+	Table     string `gorm:"column:table_name;size:100;uniqueIndex:idx_row_snapshot_run_row"`
+	RowID     string `gorm:"size:64;uniqueIndex:idx_row_snapshot_run_row"` // Chave primária da linha
+	Op        string `gorm:"size:10"`
+	Data      string `gorm:"type:text"` // Linha inteira em JSON (o model do repositório)
+}
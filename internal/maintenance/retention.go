@@ -0,0 +1,171 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/botlorien/go-rpa-template/internal/repository"
+	"github.com/botlorien/go-rpa-template/pkg/metrics"
+	"github.com/botlorien/go-rpa-template/pkg/utils"
+)
+
+// initialDelay é a espera antes da primeira limpeza depois que a API sobe.
+// Sem ela, deploys mais frequentes que o intervalo nunca deixariam a limpeza rodar;
+// com ela, a subida (migrações, browser) não disputa o banco com o purge.
+const initialDelay = time.Minute
+
+// Policy diz quantos dias manter de cada coisa. 0 = mantém para sempre.
+type Policy struct {
+	DownloadsDir    string
+	DownloadsDays   int
+	ReportsDir      string
+	ReportsDays     int
+	TableDays       map[string]int // Por tabela (ex: relatorio_pesos=365)
+	SoftDeletedDays int            // Linhas soft-deleted há mais tempo que isso saem de vez
+}
+
+// DirReport é o que a limpeza fez (ou faria) numa pasta
+type DirReport struct {
+	Dir    string    `json:"dir"`
+	Before time.Time `json:"before"`
+	Files  int       `json:"files"`
+	Bytes  int64     `json:"bytes"`
+}
+
+// TableReport é o que a limpeza fez (ou faria) numa tabela
+type TableReport struct {
+	Table  string    `json:"table"`
+	Rule   string    `json:"rule"` // "retention" (idade da linha) ou "soft_deleted"
+	Before time.Time `json:"before"`
+	Rows   int64     `json:"rows"`
+}
+
+// Report é o resultado de uma rodada de limpeza
+type Report struct {
+	DryRun bool          `json:"dry_run"`
+	Dirs   []DirReport   `json:"dirs"`
+	Tables []TableReport `json:"tables"`
+}
+
+// Retention aplica a Policy nas pastas e nas tabelas registradas
+type Retention struct {
+	Policy  Policy
+	Targets []repository.RetentionTarget
+	Now     func() time.Time // Relógio (padrão: time.Now)
+}
+
+// NewRetention monta a rotina de retenção
+func NewRetention(policy Policy, targets ...repository.RetentionTarget) *Retention {
+	return &Retention{Policy: policy, Targets: targets, Now: time.Now}
+}
+
+// Run faz uma rodada de limpeza. Com dryRun só conta o que seria removido.
+// Um alvo com erro não impede os outros: os erros voltam juntos no final.
+func (rt *Retention) Run(ctx context.Context, dryRun bool) (Report, error) {
+	report := Report{DryRun: dryRun}
+	// A retenção vale para a tabela inteira, de todos os tenants
+	ctx = repository.AllTenants(ctx)
+	now := rt.Now()
+	var errs []error
+
+	dirs := []struct {
+		name string
+		path string
+		days int
+	}{
+		{"downloads", rt.Policy.DownloadsDir, rt.Policy.DownloadsDays},
+		{"reports", rt.Policy.ReportsDir, rt.Policy.ReportsDays},
+	}
+	for _, d := range dirs {
+		if d.path == "" || d.days <= 0 {
+			continue
+		}
+		before := daysAgo(now, d.days)
+		res, err := utils.PurgeOlderThan(d.path, before, dryRun)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("limpeza de %s: %w", d.path, err))
+		}
+		report.Dirs = append(report.Dirs, DirReport{Dir: d.path, Before: before, Files: res.Files, Bytes: res.Bytes})
+		if !dryRun {
+			metrics.Purged.WithLabelValues(d.name).Add(float64(res.Files))
+		}
+	}
+
+	for _, t := range rt.Targets {
+		if days := rt.Policy.TableDays[t.Table]; days > 0 {
+			before := daysAgo(now, days)
+			n, err := t.Purger.PurgeBefore(ctx, t.Column, before, dryRun)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			report.Tables = append(report.Tables, TableReport{Table: t.Table, Rule: "retention", Before: before, Rows: n})
+			if !dryRun {
+				metrics.Purged.WithLabelValues(t.Table).Add(float64(n))
+			}
+		}
+		if rt.Policy.SoftDeletedDays > 0 {
+			before := daysAgo(now, rt.Policy.SoftDeletedDays)
+			n, err := t.Purger.PurgeSoftDeleted(ctx, before, dryRun)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			report.Tables = append(report.Tables, TableReport{Table: t.Table, Rule: "soft_deleted", Before: before, Rows: n})
+			if !dryRun {
+				metrics.Purged.WithLabelValues(t.Table).Add(float64(n))
+			}
+		}
+	}
+
+	return report, errors.Join(errs...)
+}
+
+// Schedule roda a limpeza a cada interval (a primeira um minuto depois de subir) até o ctx acabar.
+// Bloqueia: chame numa goroutine.
+func (rt *Retention) Schedule(ctx context.Context, interval time.Duration) {
+	timer := time.NewTimer(initialDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		report, err := rt.Run(ctx, false)
+		event := log.Info()
+		if err != nil {
+			event = log.Error().Err(err)
+		}
+		event.Int("arquivos", report.TotalFiles()).Int64("linhas", report.TotalRows()).
+			Dur("proxima_em", interval).Msg("Limpeza de retenção concluída")
+
+		timer.Reset(interval)
+	}
+}
+
+// TotalFiles soma os arquivos de todas as pastas
+func (r Report) TotalFiles() int {
+	total := 0
+	for _, d := range r.Dirs {
+		total += d.Files
+	}
+	return total
+}
+
+// TotalRows soma as linhas de todas as tabelas
+func (r Report) TotalRows() int64 {
+	var total int64
+	for _, t := range r.Tables {
+		total += t.Rows
+	}
+	return total
+}
+
+func daysAgo(now time.Time, days int) time.Time {
+	return now.AddDate(0, 0, -days)
+}
@@ -0,0 +1,241 @@
+// Package migrations lista as migrações do schema do template, em ordem.
+//
+// Para mudar o schema: acrescente uma Migration no fim de All() com a próxima versão.
+// Nunca edite nem renumere uma migração já publicada — crie outra que corrija.
+// As migrações usam structs "congeladas" (snapshot) em vez dos models de domain:
+// o domain evolui, mas a migração 1 precisa gerar sempre o mesmo schema.
+package migrations
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/botlorien/go-rpa-template/internal/domain"
+	"github.com/botlorien/go-rpa-template/pkg/migrate"
+
+	"gorm.io/gorm"
+)
+
+// All são as migrações do template, da mais antiga para a mais nova
+func All() []migrate.Migration {
+	return []migrate.Migration{
+		{
+			Version: 1,
+			Name:    "create_relatorio_pesos",
+			// AutoMigrate no snapshot: bancos criados pelo AutoMigrate antigo só ganham o que falta
+			Up: func(tx *gorm.DB) error {
+				if err := dedupeRelatorioPesos(tx); err != nil {
+					return err
+				}
+				return tx.Migrator().AutoMigrate(&relatorioPesoV1{})
+			},
+			Down: func(tx *gorm.DB) error { return tx.Migrator().DropTable(&relatorioPesoV1{}) },
+		},
+		{
+			Version: 2,
+			Name:    "create_executions",
+			Up:      func(tx *gorm.DB) error { return tx.Migrator().AutoMigrate(&executionV1{}) },
+			Down:    func(tx *gorm.DB) error { return tx.Migrator().DropTable(&executionV1{}) },
+		},
+		{
+			Version: 3,
+			Name:    "execution_history_and_run_lineage",
+			Up: func(tx *gorm.DB) error {
+				return tx.Migrator().AutoMigrate(&executionV3{}, &relatorioPesoV3{})
+			},
+			Down: func(tx *gorm.DB) error {
+				m := tx.Migrator()
+				for _, col := range []string{"Robot", "RobotVersion", "TriggerSource", "TriggerIdentity", "Input", "ResultSummary", "Artifacts"} {
+					if err := m.DropColumn(&executionV3{}, col); err != nil {
+						return err
+					}
+				}
+				if err := m.DropIndex(&relatorioPesoV3{}, "RunID"); err != nil {
+					return err
+				}
+				return m.DropColumn(&relatorioPesoV3{}, "RunID")
+			},
+		},
+		{
+			Version: 4,
+			Name:    "create_row_snapshots",
+			Up:      func(tx *gorm.DB) error { return tx.Migrator().AutoMigrate(&rowSnapshotV4{}) },
+			Down:    func(tx *gorm.DB) error { return tx.Migrator().DropTable(&rowSnapshotV4{}) },
+		},
+		{
+			Version: 5,
+			Name:    "tenant_scoping",
+			Up: func(tx *gorm.DB) error {
+				m := tx.Migrator()
+				// A chave natural passa a valer dentro do tenant: o índice é recriado com o tenant_id na frente
+				if m.HasIndex(&relatorioPesoV3{}, "idx_relatorio_peso_natural") {
+					if err := m.DropIndex(&relatorioPesoV3{}, "idx_relatorio_peso_natural"); err != nil {
+						return err
+					}
+				}
+				return m.AutoMigrate(&relatorioPesoV5{}, &executionV5{}, &rowSnapshotV5{})
+			},
+			Down: func(tx *gorm.DB) error {
+				m := tx.Migrator()
+				if err := m.DropIndex(&relatorioPesoV5{}, "idx_relatorio_peso_natural"); err != nil {
+					return err
+				}
+				if err := m.DropColumn(&relatorioPesoV5{}, "TenantID"); err != nil {
+					return err
+				}
+				if err := m.DropIndex(&executionV5{}, "TenantID"); err != nil {
+					return err
+				}
+				if err := m.DropColumn(&executionV5{}, "TenantID"); err != nil {
+					return err
+				}
+				if err := m.DropIndex(&rowSnapshotV5{}, "TenantID"); err != nil {
+					return err
+				}
+				if err := m.DropColumn(&rowSnapshotV5{}, "TenantID"); err != nil {
+					return err
+				}
+				// Volta o índice único antigo (destino, data)
+				return m.AutoMigrate(&relatorioPesoV3{})
+			},
+		},
+	}
+}
+
+// AutoMigrate é o modo dev (DB_AUTO_MIGRATE=true): ajusta as tabelas direto dos models de domain.
+// Não versiona nem remove nada; em produção use as migrações.
+func AutoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&domain.RelatorioPeso{},
+		&domain.Execution{},
+		&domain.RowSnapshot{},
+	)
+}
+
+// dedupeRelatorioPesos apaga as linhas repetidas (destino, data_processamento) de uma tabela
+// criada antes do índice único, ficando com a de maior id (a gravada por último).
+// Sem isso o CREATE UNIQUE INDEX da migração 1 falha nos bancos antigos, que têm duplicatas
+// (o SaveBatch antigo só fazia INSERT), e a API/CLI não sobe. Também acerta os bancos em que
+// o AutoMigrate do construtor do repositório falhou calado e o índice nunca foi criado.
+func dedupeRelatorioPesos(tx *gorm.DB) error {
+	m := tx.Migrator()
+	if !m.HasTable(&relatorioPesoV1{}) || m.HasIndex(&relatorioPesoV1{}, "idx_relatorio_peso_natural") {
+		return nil
+	}
+	// A subquery vai numa tabela derivada: o MySQL não deixa o DELETE ler a própria tabela direto
+	result := tx.Exec(`DELETE FROM relatorio_pesos WHERE id NOT IN (
+		SELECT id FROM (SELECT MAX(id) AS id FROM relatorio_pesos GROUP BY destino, data_processamento) keep
+	)`)
+	if result.Error != nil {
+		return fmt.Errorf("falha ao remover duplicatas de relatorio_pesos: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		fmt.Printf("🧹 relatorio_pesos: %d linhas duplicadas (destino, data) removidas antes do índice único\n", result.RowsAffected)
+	}
+	return nil
+}
+
+// --- Snapshots (não alterar) ---
+
+type relatorioPesoV1 struct {
+	gorm.Model
+	Destino           string    `gorm:"size:191;uniqueIndex:idx_relatorio_peso_natural;index"`
+	PesoCalculoTotal  float64   `gorm:"type:decimal(15,2)"`
+	DataProcessamento time.Time `gorm:"uniqueIndex:idx_relatorio_peso_natural"`
+}
+
+func (relatorioPesoV1) TableName() string { return "relatorio_pesos" }
+
+type executionV1 struct {
+	gorm.Model
+	RunID      string `gorm:"uniqueIndex;size:36"`
+	Status     string `gorm:"index;size:20"`
+	StartedAt  time.Time
+	FinishedAt *time.Time
+	Error      string
+}
+
+func (executionV1) TableName() string { return "executions" }
+
+type executionV3 struct {
+	gorm.Model
+	RunID           string `gorm:"uniqueIndex;size:36"`
+	Robot           string `gorm:"index;size:100"`
+	RobotVersion    string `gorm:"size:100"`
+	TriggerSource   string `gorm:"size:20"`
+	TriggerIdentity string `gorm:"size:191"`
+	Input           string `gorm:"type:text"`
+	Status          string `gorm:"index;size:20"`
+	StartedAt       time.Time
+	FinishedAt      *time.Time
+	Error           string
+	ResultSummary   string `gorm:"type:text"`
+	Artifacts       string `gorm:"type:text"`
+}
+
+func (executionV3) TableName() string { return "executions" }
+
+type relatorioPesoV3 struct {
+	gorm.Model
+	Destino           string    `gorm:"size:191;uniqueIndex:idx_relatorio_peso_natural;index"`
+	PesoCalculoTotal  float64   `gorm:"type:decimal(15,2)"`
+	DataProcessamento time.Time `gorm:"uniqueIndex:idx_relatorio_peso_natural"`
+	RunID             string    `gorm:"size:36;index"`
+}
+
+func (relatorioPesoV3) TableName() string { return "relatorio_pesos" }
+
+type rowSnapshotV4 struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	RunID     string `gorm:"size:36;uniqueIndex:idx_row_snapshot_run_row"`
+	Table     string `gorm:"column:table_name;size:100;uniqueIndex:idx_row_snapshot_run_row"`
+	RowID     string `gorm:"size:64;uniqueIndex:idx_row_snapshot_run_row"`
+	Op        string `gorm:"size:10"`
+	Data      string `gorm:"type:text"`
+}
+
+func (rowSnapshotV4) TableName() string { return "row_snapshots" }
+
+type relatorioPesoV5 struct {
+	gorm.Model
+	Destino           string    `gorm:"size:191;uniqueIndex:idx_relatorio_peso_natural;index"`
+	PesoCalculoTotal  float64   `gorm:"type:decimal(15,2)"`
+	DataProcessamento time.Time `gorm:"uniqueIndex:idx_relatorio_peso_natural"`
+	RunID             string    `gorm:"size:36;index"`
+	TenantID          string    `gorm:"size:64;not null;default:'';uniqueIndex:idx_relatorio_peso_natural,priority:1"`
+}
+
+func (relatorioPesoV5) TableName() string { return "relatorio_pesos" }
+
+type executionV5 struct {
+	gorm.Model
+	RunID           string `gorm:"uniqueIndex;size:36"`
+	TenantID        string `gorm:"size:64;not null;default:'';index"`
+	Robot           string `gorm:"index;size:100"`
+	RobotVersion    string `gorm:"size:100"`
+	TriggerSource   string `gorm:"size:20"`
+	TriggerIdentity string `gorm:"size:191"`
+	Input           string `gorm:"type:text"`
+	Status          string `gorm:"index;size:20"`
+	StartedAt       time.Time
+	FinishedAt      *time.Time
+	Error           string
+	ResultSummary   string `gorm:"type:text"`
+	Artifacts       string `gorm:"type:text"`
+}
+
+func (executionV5) TableName() string { return "executions" }
+
+type rowSnapshotV5 struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	RunID     string `gorm:"size:36;uniqueIndex:idx_row_snapshot_run_row"`
+	TenantID  string `gorm:"size:64;not null;default:'';index"`
+	Table     string `gorm:"column:table_name;size:100;uniqueIndex:idx_row_snapshot_run_row"`
+	RowID     string `gorm:"size:64;uniqueIndex:idx_row_snapshot_run_row"`
+	Op        string `gorm:"size:10"`
+	Data      string `gorm:"type:text"`
+}
+
+func (rowSnapshotV5) TableName() string { return "row_snapshots" }
@@ -81,20 +81,16 @@ func (df *DataFrame) Filter(condition func(row Row) bool) *DataFrame {
 // --- Helpers de Conversão (Padrão Brasil) ---
 
 // GetFloat converte "1.234,56" para float64
+// Valor inválido vira 0; para ter o erro use ParseFloatBR (ou o Unmarshal com tag br_float).
 func (r Row) GetFloatBR(col string) float64 {
 	val, ok := r[col]
 	if !ok || val == "" {
 		return 0.0
 	}
-	// Remove pontos de milhar e troca vírgula decimal por ponto
-	clean := strings.ReplaceAll(val, ".", "")
-	clean = strings.ReplaceAll(clean, ",", ".")
-	
-	f, _ := strconv.ParseFloat(clean, 64)
+	f, _ := ParseFloatBR(val)
 	return f
 }
 
-
 func (r Row) GetFloatStd(col string) float64 {
 	val, ok := r[col]
 	if !ok || val == "" {
@@ -117,45 +113,15 @@ func (r Row) GetInt(col string) int {
 
 // GetDate tenta converter a string em data testando múltiplos formatos
 // Se layout for informado, ele tenta APENAS aquele layout.
-// Se layout for vazio, ele tenta adivinhar usando a lista DateLayouts.
+// Se layout for vazio, ele tenta adivinhar usando a lista DateLayouts (e a data serial do Excel).
+// Falhou tudo = time.Time{}; o ParseDate devolve o erro.
 func (r Row) GetDate(col string, specificLayout string) time.Time {
 	val, ok := r[col]
 	if !ok || val == "" {
 		return time.Time{}
 	}
-
-	// 1. Se o usuário passou um layout específico, respeitamos (Performance e Precisão)
-	if specificLayout != "" {
-		t, err := time.Parse(specificLayout, val)
-		if err == nil {
-			return t
-		}
-		// Se falhar o específico, podemos retornar zero ou tentar o fallback.
-		// Aqui retornamos zero para indicar erro de contrato.
-		return time.Time{}
-	}
-
-	// 2. Tentativa Automática (Brute Force inteligente)
-	for _, layout := range DateLayouts {
-		// Dica: time.Parse é estrito. Se sobrar caracter, ele falha.
-		// Isso é bom para evitar falso positivo.
-		t, err := time.Parse(layout, val)
-		if err == nil {
-			return t
-		}
-	}
-
-	// 3. Casos Especiais (Excel Serial Date)
-	// Às vezes o Excelize retorna "45250" em vez de "25/11/2023"
-	if excelSerial, err := strconv.ParseFloat(val, 64); err == nil {
-		// Data base do Excel (quase sempre é 30/Dez/1899)
-		baseDate := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
-		// Converte dias para Nanosegundos e soma
-		daysInNano := time.Duration(excelSerial * 24 * float64(time.Hour))
-		return baseDate.Add(daysInNano)
-	}
-
-	return time.Time{} // Falhou tudo
+	t, _ := ParseDate(val, specificLayout)
+	return t
 }
 
 // String implementa a interface fmt.Stringer.
@@ -208,6 +174,26 @@ func (df *DataFrame) String() string {
 	return buf.String()
 }
 
+// resultHeadRows é quantas linhas o resumo do DataFrame leva para a dashboard
+const resultHeadRows = 10
+
+// ResultData resume o DataFrame para o result_data da dashboard (implementa botapp.Resulter).
+// Mandar todas as linhas incharia o log; contagem, headers e o começo bastam para conferência.
+func (df *DataFrame) ResultData() any {
+	if df == nil {
+		return map[string]any{"rows": 0, "headers": []string{}, "head": []Row{}}
+	}
+	head := df.Rows
+	if len(head) > resultHeadRows {
+		head = head[:resultHeadRows]
+	}
+	return map[string]any{
+		"rows":    len(df.Rows),
+		"headers": df.Headers,
+		"head":    head,
+	}
+}
+
 // Head é um helper para imprimir manualmente apenas o começo (se quiser variar o limite)
 func (df *DataFrame) Head(n int) {
 	fmt.Printf("--- Head (%d) ---\n", n)
@@ -13,6 +13,8 @@ import (
 	"github.com/xuri/excelize/v2"
 	"golang.org/x/text/encoding/charmap" // go get golang.org/x/text
 	"golang.org/x/text/transform"
+
+	"github.com/botlorien/go-rpa-template/pkg/metrics"
 )
 
 // LoadFile detecta a extensão e carrega os dados normalizados
@@ -137,5 +139,6 @@ func parseRawRows(rawRows [][]string) (*DataFrame, error) {
 		df.Rows = append(df.Rows, rowMap)
 	}
 
+	metrics.RowsProcessed.Add(float64(len(df.Rows)))
 	return df, nil
 }
\ No newline at end of file
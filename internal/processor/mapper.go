@@ -0,0 +1,390 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mapeamento DataFrame -> slice de structs (models do GORM) por struct tag:
+//
+//	type RelatorioPeso struct {
+//		gorm.Model
+//		Destino          string    `df:"Destino,required"`
+//		PesoCalculoTotal float64   `df:"Peso Cálculo Total,br_float"`
+//		Emissao          time.Time `df:"Emissão,date=02/01/2006"`
+//	}
+//
+// Formato da tag: "Cabeçalho[,conversor][,opções]". Campos sem tag df ficam de fora (ID, datas do gorm.Model etc).
+// Conversores: string, br_float, float, int, bool, date (adivinha pelo DateLayouts), date=LAYOUT, br_date.
+// Sem conversor, vale o padrão do tipo do campo (float64 -> float, time.Time -> date...).
+// Opções: required (célula vazia vira erro) e optional (coluna pode não existir no arquivo).
+// Célula vazia deixa o campo no valor zero (ou nil, se for ponteiro).
+
+// Converter transforma o texto da célula no valor do campo
+type Converter func(val string) (any, error)
+
+// Converters são os conversores aceitos na tag df. Dá para registrar novos no init do robô.
+// "date=LAYOUT" é tratado à parte (o layout vem na própria tag).
+var Converters = map[string]Converter{
+	"string":   func(val string) (any, error) { return val, nil },
+	"br_float": func(val string) (any, error) { return ParseFloatBR(val) },
+	"float":    func(val string) (any, error) { return strconv.ParseFloat(val, 64) },
+	"int":      func(val string) (any, error) { return strconv.ParseInt(val, 10, 64) },
+	"bool":     func(val string) (any, error) { return ParseBool(val) },
+	"date":     func(val string) (any, error) { return ParseDate(val, "") },
+	"br_date":  func(val string) (any, error) { return parseLayouts(val, brDateLayouts) },
+}
+
+// brDateLayouts são os formatos de data com o dia na frente (sem adivinhar ISO/americano)
+var brDateLayouts = []string{"02/01/2006 15:04:05", "02/01/2006 15:04", "02/01/2006", "02-01-2006", "02.01.2006"}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// CellError é uma célula que não pôde ser convertida.
+// Row é a linha na planilha (o cabeçalho é a linha 1) e Column a coluna (começando em 1).
+type CellError struct {
+	Row    int
+	Column int
+	Header string
+	Value  string
+	Err    error
+}
+
+func (e CellError) Error() string {
+	if e.Row <= 1 {
+		return fmt.Sprintf("coluna %q: %v", e.Header, e.Err)
+	}
+	return fmt.Sprintf("linha %d, coluna %d (%s): valor %q: %v", e.Row, e.Column, e.Header, e.Value, e.Err)
+}
+
+func (e CellError) Unwrap() error { return e.Err }
+
+// MappingError junta todos os erros de conversão do DataFrame, em vez de parar no primeiro
+type MappingError struct {
+	Errors []CellError
+}
+
+// mappingErrorPreview é quantos erros aparecem na mensagem (a lista completa fica em Errors)
+const mappingErrorPreview = 5
+
+func (e *MappingError) Error() string {
+	msgs := make([]string, 0, mappingErrorPreview)
+	for i, cell := range e.Errors {
+		if i == mappingErrorPreview {
+			msgs = append(msgs, fmt.Sprintf("... mais %d", len(e.Errors)-mappingErrorPreview))
+			break
+		}
+		msgs = append(msgs, cell.Error())
+	}
+	return fmt.Sprintf("%d erro(s) de conversão: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// dfField é um campo da struct ligado a uma coluna do DataFrame
+type dfField struct {
+	index    []int
+	header   string // Cabeçalho como está no DataFrame
+	column   int
+	convert  Converter
+	required bool
+}
+
+// Decode é o Unmarshal tipado: devolve o slice já pronto para o repositório.
+//
+//	dados, err := processor.Decode[domain.RelatorioPeso](df)
+func Decode[T any](df *DataFrame) ([]T, error) {
+	var out []T
+	err := Unmarshal(df, &out)
+	return out, err
+}
+
+// Unmarshal preenche out (*[]T ou *[]*T) com as linhas do DataFrame, seguindo as tags df.
+//
+// Linhas com erro ficam de fora do slice e os erros voltam juntos num *MappingError,
+// com linha e coluna de cada célula. Quem chama decide se grava o que deu certo ou aborta.
+// Coluna obrigatória ausente no arquivo aborta antes de converter qualquer linha.
+func Unmarshal(df *DataFrame, out any) error {
+	ptr := reflect.ValueOf(out)
+	if ptr.Kind() != reflect.Pointer || ptr.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("unmarshal espera ponteiro para slice, recebeu %T", out)
+	}
+	slice := ptr.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if elemType.Kind() == reflect.Pointer {
+		structType = elemType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("unmarshal espera slice de structs, recebeu %T", out)
+	}
+	if df == nil {
+		return errors.New("dataframe nulo")
+	}
+
+	fields, err := fieldsFor(structType, df.Headers)
+	if err != nil {
+		return err
+	}
+
+	var mapErr MappingError
+	result := reflect.MakeSlice(slice.Type(), 0, len(df.Rows))
+	for i, row := range df.Rows {
+		item := reflect.New(structType).Elem()
+		ok := true
+		for _, f := range fields {
+			val, present := row[f.header]
+			if !present && f.column == 0 {
+				continue // Coluna opcional ausente do arquivo
+			}
+			if err := setField(item.FieldByIndex(f.index), val, f); err != nil {
+				mapErr.Errors = append(mapErr.Errors, CellError{Row: i + 2, Column: f.column, Header: f.header, Value: val, Err: err})
+				ok = false
+			}
+		}
+		if !ok {
+			continue
+		}
+		if elemType.Kind() == reflect.Pointer {
+			result = reflect.Append(result, item.Addr())
+		} else {
+			result = reflect.Append(result, item)
+		}
+	}
+	slice.Set(result)
+
+	if len(mapErr.Errors) > 0 {
+		return &mapErr
+	}
+	return nil
+}
+
+// fieldsFor lê as tags df da struct e acha a coluna de cada campo no cabeçalho.
+// A busca ignora maiúsculas/minúsculas e espaços nas pontas (o loader já faz o strip).
+func fieldsFor(t reflect.Type, headers []string) ([]dfField, error) {
+	var fields []dfField
+	var missing MappingError
+
+	for _, sf := range reflect.VisibleFields(t) {
+		tag, ok := sf.Tag.Lookup("df")
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		f := dfField{index: sf.Index, header: strings.TrimSpace(parts[0])}
+		optional := false
+
+		for _, opt := range parts[1:] {
+			opt = strings.TrimSpace(opt)
+			switch {
+			case opt == "required":
+				f.required = true
+			case opt == "optional":
+				optional = true
+			case strings.HasPrefix(opt, "date="):
+				layout := strings.TrimPrefix(opt, "date=")
+				f.convert = func(val string) (any, error) { return ParseDate(val, layout) }
+			case opt != "":
+				conv, ok := Converters[opt]
+				if !ok {
+					return nil, fmt.Errorf("campo %s: conversor desconhecido na tag df: %q", sf.Name, opt)
+				}
+				f.convert = conv
+			}
+		}
+		if f.convert == nil {
+			conv, err := defaultConverter(sf.Type)
+			if err != nil {
+				return nil, fmt.Errorf("campo %s: %w", sf.Name, err)
+			}
+			f.convert = conv
+		}
+
+		for i, h := range headers {
+			if strings.EqualFold(strings.TrimSpace(h), f.header) {
+				f.header, f.column = h, i+1
+				break
+			}
+		}
+		if f.column == 0 && !optional {
+			missing.Errors = append(missing.Errors, CellError{Row: 1, Header: f.header, Err: errors.New("coluna não encontrada no arquivo")})
+		}
+		fields = append(fields, f)
+	}
+
+	if len(missing.Errors) > 0 {
+		return nil, &missing
+	}
+	return fields, nil
+}
+
+// defaultConverter escolhe o conversor pelo tipo do campo quando a tag não diz qual
+func defaultConverter(t reflect.Type) (Converter, error) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return Converters["date"], nil
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return Converters["string"], nil
+	case reflect.Float32, reflect.Float64:
+		return Converters["float"], nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Converters["int"], nil
+	case reflect.Bool:
+		return Converters["bool"], nil
+	}
+	return nil, fmt.Errorf("tipo %s sem conversor padrão (informe um na tag df)", t)
+}
+
+// setField converte a célula e grava no campo (alocando se for ponteiro)
+func setField(field reflect.Value, val string, f dfField) error {
+	if val == "" {
+		if f.required {
+			return errors.New("valor obrigatório")
+		}
+		return nil // Fica o valor zero (ou nil)
+	}
+
+	converted, err := f.convert(val)
+	if err != nil {
+		return err
+	}
+
+	if field.Kind() == reflect.Pointer {
+		ptr := reflect.New(field.Type().Elem())
+		if err := assign(ptr.Elem(), converted); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+	return assign(field, converted)
+}
+
+// assign grava o valor convertido no campo, checando tipo e overflow
+func assign(field reflect.Value, converted any) error {
+	v := reflect.ValueOf(converted)
+
+	switch field.Kind() {
+	case reflect.Float32, reflect.Float64:
+		switch n := converted.(type) {
+		case float64:
+			field.SetFloat(n)
+			return nil
+		case int64:
+			field.SetFloat(float64(n))
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch c := converted.(type) {
+		case int64:
+			n = c
+		case float64:
+			if c != float64(int64(c)) {
+				return fmt.Errorf("%v não é inteiro", c)
+			}
+			n = int64(c)
+		default:
+			return fmt.Errorf("conversor devolveu %T para campo %s", converted, field.Type())
+		}
+		if field.OverflowInt(n) {
+			return fmt.Errorf("%d não cabe em %s", n, field.Type())
+		}
+		field.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := converted.(int64)
+		if !ok {
+			return fmt.Errorf("conversor devolveu %T para campo %s", converted, field.Type())
+		}
+		if n < 0 || field.OverflowUint(uint64(n)) {
+			return fmt.Errorf("%d não cabe em %s", n, field.Type())
+		}
+		field.SetUint(uint64(n))
+		return nil
+	}
+
+	if v.Type().AssignableTo(field.Type()) {
+		field.Set(v)
+		return nil
+	}
+	if v.Type().ConvertibleTo(field.Type()) && v.Kind() == field.Kind() {
+		field.Set(v.Convert(field.Type()))
+		return nil
+	}
+	return fmt.Errorf("conversor devolveu %T para campo %s", converted, field.Type())
+}
+
+// --- Conversores (versões com erro dos helpers Get* da Row) ---
+
+// ParseFloatBR converte "1.234,56" (ou "R$ 1.234,56") para float64
+func ParseFloatBR(val string) (float64, error) {
+	clean := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(val), "R$"))
+	// Remove pontos de milhar e troca vírgula decimal por ponto
+	clean = strings.ReplaceAll(clean, ".", "")
+	clean = strings.ReplaceAll(clean, ",", ".")
+
+	f, err := strconv.ParseFloat(clean, 64)
+	if err != nil {
+		return 0, fmt.Errorf("número inválido: %q", val)
+	}
+	return f, nil
+}
+
+// FormatFloatBR é o inverso do ParseFloatBR: 1234.56 vira "1234,56" (vírgula decimal, sem milhar)
+func FormatFloatBR(f float64, decimals int) string {
+	return strings.Replace(strconv.FormatFloat(f, 'f', decimals, 64), ".", ",", 1)
+}
+
+// ParseDate converte a data no layout informado ou, sem layout, adivinhando pelo DateLayouts
+// (e pela data serial do Excel, ex: "45250")
+func ParseDate(val, layout string) (time.Time, error) {
+	if layout != "" {
+		t, err := time.Parse(layout, val)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("data fora do formato %s", layout)
+		}
+		return t, nil
+	}
+
+	if t, err := parseLayouts(val, DateLayouts); err == nil {
+		return t, nil
+	}
+
+	// Às vezes o Excelize retorna "45250" em vez de "25/11/2023"
+	if excelSerial, err := strconv.ParseFloat(val, 64); err == nil {
+		// Data base do Excel (quase sempre é 30/Dez/1899)
+		baseDate := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+		return baseDate.Add(time.Duration(excelSerial * 24 * float64(time.Hour))), nil
+	}
+
+	return time.Time{}, errors.New("data em formato desconhecido")
+}
+
+func parseLayouts(val string, layouts []string) (time.Time, error) {
+	for _, layout := range layouts {
+		// time.Parse é estrito: se sobrar caracter ele falha (evita falso positivo)
+		if t, err := time.Parse(layout, val); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("data em formato desconhecido")
+}
+
+// ParseBool entende os jeitos comuns de sim/não das planilhas
+func ParseBool(val string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(val)) {
+	case "1", "s", "sim", "y", "yes", "true", "verdadeiro", "x":
+		return true, nil
+	case "0", "n", "não", "nao", "no", "false", "falso":
+		return false, nil
+	}
+	return false, fmt.Errorf("booleano inválido: %q", val)
+}
@@ -0,0 +1,186 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrFull é retornado quando a fila de espera já está no limite
+	ErrFull = errors.New("fila de execuções cheia")
+	// ErrClosed é retornado quando a fila não aceita mais execuções (shutdown em andamento)
+	ErrClosed = errors.New("fila de execuções encerrada")
+)
+
+// Job é a unidade de trabalho executada pela fila
+type Job func(ctx context.Context) (any, error)
+
+// Stats é uma fotografia do estado da fila
+type Stats struct {
+	Running    int  `json:"running"`
+	Waiting    int  `json:"waiting"`
+	Capacity   int  `json:"capacity"`
+	MaxWaiting int  `json:"max_waiting"`
+	Closed     bool `json:"closed"`
+}
+
+// Queue limita quantas execuções do robô rodam ao mesmo tempo.
+// Quem excede a capacidade espera numa fila limitada; quem excede a fila recebe ErrFull.
+//
+// Os jobs rodam com um contexto desligado do contexto da requisição (o cliente HTTP
+// desconectar não interrompe uma carga no meio), mas que é cancelado por Cancel()
+// durante o shutdown.
+type Queue struct {
+	slots      chan struct{}
+	maxWaiting int
+
+	baseCtx context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex
+	running int
+	waiting int
+	closed  bool
+}
+
+// New cria uma fila com maxConcurrent execuções simultâneas e até maxWaiting aguardando
+func New(maxConcurrent, maxWaiting int) *Queue {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	if maxWaiting < 0 {
+		maxWaiting = 0
+	}
+	baseCtx, cancel := context.WithCancel(context.Background())
+	return &Queue{
+		slots:      make(chan struct{}, maxConcurrent),
+		maxWaiting: maxWaiting,
+		baseCtx:    baseCtx,
+		cancel:     cancel,
+	}
+}
+
+// Do aguarda um slot livre e executa o job.
+// Se o contexto for cancelado enquanto espera, retorna ctx.Err() sem executar.
+func (q *Queue) Do(ctx context.Context, job Job) (any, error) {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil, ErrClosed
+	}
+	q.wg.Add(1)
+	q.mu.Unlock()
+	defer q.wg.Done()
+
+	if err := q.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer q.release()
+
+	// Mantém os valores do contexto da requisição, mas o cancelamento vem só da fila
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+	stop := context.AfterFunc(q.baseCtx, cancel)
+	defer stop()
+
+	return job(jobCtx)
+}
+
+func (q *Queue) acquire(ctx context.Context) error {
+	// Caminho rápido: tem slot livre
+	select {
+	case q.slots <- struct{}{}:
+		q.mu.Lock()
+		q.running++
+		q.mu.Unlock()
+		return nil
+	default:
+	}
+
+	q.mu.Lock()
+	if q.waiting >= q.maxWaiting {
+		q.mu.Unlock()
+		return ErrFull
+	}
+	q.waiting++
+	q.mu.Unlock()
+
+	select {
+	case q.slots <- struct{}{}:
+		q.mu.Lock()
+		q.waiting--
+		q.running++
+		q.mu.Unlock()
+		return nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		q.waiting--
+		q.mu.Unlock()
+		return ctx.Err()
+	case <-q.baseCtx.Done():
+		q.mu.Lock()
+		q.waiting--
+		q.mu.Unlock()
+		return ErrClosed
+	}
+}
+
+func (q *Queue) release() {
+	q.mu.Lock()
+	q.running--
+	q.mu.Unlock()
+	<-q.slots
+}
+
+// Close para de aceitar novas execuções. As que já estão na fila continuam.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+}
+
+// Drain fecha a fila e espera as execuções em andamento terminarem.
+// Retorna ctx.Err() se o prazo acabar antes disso.
+func (q *Queue) Drain(ctx context.Context) error {
+	q.Close()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Cancel cancela o contexto de todas as execuções em andamento e descarta as que aguardam
+func (q *Queue) Cancel() {
+	q.Close()
+	q.cancel()
+}
+
+// Stats retorna o estado atual da fila
+func (q *Queue) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return Stats{
+		Running:    q.running,
+		Waiting:    q.waiting,
+		Capacity:   cap(q.slots),
+		MaxWaiting: q.maxWaiting,
+		Closed:     q.closed,
+	}
+}
+
+// Saturated indica que a fila não aceita mais nenhuma execução
+func (q *Queue) Saturated() bool {
+	st := q.Stats()
+	return st.Closed || (st.Running >= st.Capacity && st.Waiting >= st.MaxWaiting)
+}
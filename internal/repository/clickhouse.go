@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// upsertClickHouse é o Upsert no ClickHouse, que não tem ON CONFLICT nem índice único:
+// lê as linhas do lote que já existem (pela chave natural), guarda o snapshot, apaga essas
+// linhas e insere o lote reaproveitando o ID e o created_at delas. Assim a linha "atualizada"
+// continua com a mesma chave primária (cursor da listagem e rollback seguem funcionando).
+//
+// O DELETE lá é uma mutation (ALTER TABLE ... DELETE), síncrona pelo mutations_sync do DSN
+// (ver database.clickhouseDSN). Não há transação de verdade no ClickHouse: se o processo cair
+// entre o DELETE e o INSERT, as linhas do lote somem até a próxima execução regravar o dia.
+func (r *Repository[T]) upsertClickHouse(ctx context.Context, batch []T, conflictKeys, updateCols []string, keys []*schema.Field, tuples [][]any) (UpsertResult, error) {
+	var res UpsertResult
+	sch, err := r.schema()
+	if err != nil {
+		return res, err
+	}
+	pk := sch.PrioritizedPrimaryField
+	if pk == nil {
+		return res, fmt.Errorf("%s sem chave primária: upsert no clickhouse precisa dela", sch.Table)
+	}
+	var update []*schema.Field
+	if len(updateCols) > 0 {
+		if update, err = r.fields(updateCols); err != nil {
+			return res, err
+		}
+	}
+
+	db := r.DB.WithContext(ctx)
+
+	// Linhas que já existem, pela chave natural (inclusive soft-deleted: o upsert traz de volta)
+	current := make(map[string]T)
+	for start := 0; start < len(tuples); start += r.batchSize() {
+		end := min(start+r.batchSize(), len(tuples))
+		var rows []T
+		if err := db.Unscoped().Where(tupleIn(db, conflictKeys, tuples[start:end])).Find(&rows).Error; err != nil {
+			return res, err
+		}
+		for i := range rows {
+			current[keyString(valuesOf(ctx, &rows[i], keys))] = rows[i]
+		}
+	}
+
+	if err := r.snapshotConflicts(db, conflictKeys, tuples); err != nil {
+		return res, err
+	}
+
+	now := time.Now()
+	runID := RunIDFrom(ctx)
+	rows := make([]T, len(batch))
+	var replaced []any
+	for i := range batch {
+		old, ok := current[keyString(tuples[i])]
+		if !ok {
+			rows[i] = batch[i]
+			continue
+		}
+		oldRV := reflect.ValueOf(&old).Elem()
+		id, _ := pk.ValueOf(ctx, oldRV)
+		replaced = append(replaced, id)
+
+		if len(update) == 0 {
+			// UpdateAll: a linha nova inteira, com a identidade da antiga
+			rows[i] = batch[i]
+			rv := reflect.ValueOf(&rows[i]).Elem()
+			if err := copyFields(ctx, rv, oldRV, pk, sch.LookUpField("CreatedAt")); err != nil {
+				return res, err
+			}
+			continue
+		}
+
+		// Colunas explícitas: a linha antiga com updateCols vindas do lote, igual o DoUpdates dos outros bancos
+		rows[i] = old
+		rv := reflect.ValueOf(&rows[i]).Elem()
+		if err := copyFields(ctx, rv, reflect.ValueOf(&batch[i]).Elem(), update...); err != nil {
+			return res, err
+		}
+		if f := sch.LookUpField("DeletedAt"); f != nil && !contains(updateCols, "deleted_at") {
+			f.ReflectValueOf(ctx, rv).Set(reflect.Zero(f.FieldType))
+		}
+		if f := sch.LookUpField("UpdatedAt"); f != nil && !contains(updateCols, "updated_at") {
+			if err := f.Set(ctx, rv, now); err != nil {
+				return res, err
+			}
+		}
+		if f := sch.LookUpField(lineageField); f != nil && runID != "" && !contains(updateCols, "run_id") {
+			if err := f.Set(ctx, rv, runID); err != nil {
+				return res, err
+			}
+		}
+	}
+
+	for start := 0; start < len(replaced); start += r.batchSize() {
+		end := min(start+r.batchSize(), len(replaced))
+		if err := db.Unscoped().Where(fmt.Sprintf("%s IN ?", pk.DBName), replaced[start:end]).Delete(new(T)).Error; err != nil {
+			return res, err
+		}
+	}
+	if err := db.CreateInBatches(rows, r.batchSize()).Error; err != nil {
+		return res, err
+	}
+
+	// Os IDs (gerados ou reaproveitados) voltam para quem chamou, como no INSERT dos outros bancos
+	for i := range batch {
+		id, _ := pk.ValueOf(ctx, reflect.ValueOf(&rows[i]).Elem())
+		if err := pk.Set(ctx, reflect.ValueOf(&batch[i]).Elem(), id); err != nil {
+			return res, err
+		}
+	}
+
+	res.Updated = int64(len(replaced))
+	res.Inserted = int64(len(batch)) - res.Updated
+	return res, nil
+}
+
+// valuesOf lê os valores dos campos de uma linha (ex: a chave natural)
+func valuesOf[T any](ctx context.Context, row *T, fields []*schema.Field) []any {
+	rv := reflect.ValueOf(row).Elem()
+	values := make([]any, len(fields))
+	for i, f := range fields {
+		values[i], _ = f.ValueOf(ctx, rv)
+	}
+	return values
+}
+
+// copyFields copia os campos de src para dst (campo nil é ignorado)
+func copyFields(ctx context.Context, dst, src reflect.Value, fields ...*schema.Field) error {
+	for _, f := range fields {
+		if f == nil {
+			continue
+		}
+		v, _ := f.ValueOf(ctx, src)
+		if err := f.Set(ctx, dst, v); err != nil {
+			return fmt.Errorf("coluna %s: %w", f.DBName, err)
+		}
+	}
+	return nil
+}
+
+// rollbackDeleted conta as linhas que o DELETE do rollback vai tirar. No ClickHouse o DELETE é uma
+// mutation e não devolve linhas afetadas: a conta sai de um COUNT antes; nos outros, do próprio DELETE.
+func rollbackDeleted(tx *gorm.DB, del func() *gorm.DB, model any) (int64, error) {
+	var counted int64
+	if tx.Dialector.Name() == "clickhouse" {
+		if err := del().Model(model).Count(&counted).Error; err != nil {
+			return 0, err
+		}
+	}
+	deleted := del().Delete(model)
+	if deleted.Error != nil {
+		return 0, deleted.Error
+	}
+	if tx.Dialector.Name() == "clickhouse" {
+		return counted, nil
+	}
+	return deleted.RowsAffected, nil
+}
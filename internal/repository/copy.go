@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/botlorien/go-rpa-template/pkg/database"
+	"github.com/botlorien/go-rpa-template/pkg/metrics"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	mssql "github.com/microsoft/go-mssqldb"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// DefaultCopyThreshold é a partir de quantas linhas o BulkInsert usa o caminho rápido do banco.
+// Abaixo disso o CreateInBatches é rápido o bastante e não vale pegar uma conexão dedicada.
+const DefaultCopyThreshold = 5000
+
+// bulkLoaders são os caminhos rápidos de carga por banco (nome do Dialector do GORM):
+// COPY FROM no postgres, bulk copy (TDS) no SQL Server e INSERT em bloco nativo no ClickHouse.
+var bulkLoaders = map[string]func(ctx context.Context, db *gorm.DB, table string, cols []string, rows [][]any) (int64, error){
+	"postgres":   copyFromPostgres,
+	"sqlserver":  copyInSQLServer,
+	"clickhouse": batchClickHouse,
+}
+
+// BulkInsert insere o lote sem checar conflito (carga "append" ou tabela de staging).
+// Em postgres, SQL Server e ClickHouse, lotes a partir de CopyThreshold vão pelo protocolo
+// de carga do banco, bem mais rápido que INSERT; nos outros (e em lotes pequenos) cai no CreateInBatches.
+//
+// O caminho rápido pula os hooks do GORM (BeforeCreate etc.) e não devolve os IDs gerados
+// pelo banco: se precisar deles, use Upsert. No ClickHouse os IDs são gerados no cliente
+// e voltam no lote. CreatedAt/UpdatedAt zerados são preenchidos com agora
+// e o run_id com o da execução no ctx.
+func (r *Repository[T]) BulkInsert(ctx context.Context, batch []T) (int64, error) {
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	var inserted int64
+	dialect := r.DB.Dialector.Name()
+	if load, ok := bulkLoaders[dialect]; ok && len(batch) >= r.copyThreshold() {
+		table, cols, rows, err := r.bulkRows(ctx, batch)
+		if err != nil {
+			return 0, fmt.Errorf("erro ao preparar carga de %s: %w", r.table(), err)
+		}
+		n, err := load(ctx, r.DB, table, cols, rows)
+		if err != nil {
+			return 0, fmt.Errorf("erro na carga em massa (%s) de %s: %w", dialect, r.table(), err)
+		}
+		inserted = n
+	} else {
+		result := r.DB.WithContext(ctx).CreateInBatches(batch, r.batchSize())
+		if result.Error != nil {
+			return 0, fmt.Errorf("erro ao inserir em %s: %w", r.table(), result.Error)
+		}
+		inserted = result.RowsAffected
+	}
+
+	metrics.RowsSaved.WithLabelValues(r.table()).Add(float64(inserted))
+	return inserted, nil
+}
+
+// bulkRows converte o lote em colunas + valores crus, do jeito que o INSERT do GORM faria
+func (r *Repository[T]) bulkRows(ctx context.Context, batch []T) (string, []string, [][]any, error) {
+	// A carga em massa não passa pelos callbacks: tenant carimbado (e conferido) aqui, ver RegisterTenancy
+	if err := r.stampTenant(ctx, batch); err != nil {
+		return "", nil, nil, err
+	}
+
+	stmt := &gorm.Statement{DB: r.DB}
+	if err := stmt.Parse(new(T)); err != nil {
+		return "", nil, nil, err
+	}
+
+	// Colunas graváveis; a chave autoincremento fica com o banco (igual o INSERT do GORM),
+	// menos no ClickHouse, que não tem autoincremento: lá o ID é gerado aqui (ver database.NewID)
+	genIDs := r.DB.Dialector.Name() == "clickhouse"
+	var fields []*schema.Field
+	var cols []string
+	for _, f := range stmt.Schema.Fields {
+		if f.DBName == "" || !f.Creatable || (f.AutoIncrement && !genIDs) {
+			continue
+		}
+		fields = append(fields, f)
+		cols = append(cols, f.DBName)
+	}
+
+	now := time.Now()
+	runID := RunIDFrom(ctx)
+	rows := make([][]any, len(batch))
+	for i := range batch {
+		rv := reflect.ValueOf(&batch[i]).Elem()
+		row := make([]any, len(fields))
+		for j, f := range fields {
+			// A carga em massa não passa pelos callbacks: carimba o run_id aqui (ver RegisterLineage)
+			if f.Name == lineageField && runID != "" {
+				if _, zero := f.ValueOf(ctx, rv); zero {
+					if err := f.Set(ctx, rv, runID); err != nil {
+						return "", nil, nil, err
+					}
+				}
+			}
+			if f.AutoIncrement {
+				if _, zero := f.ValueOf(ctx, rv); zero {
+					if err := f.Set(ctx, rv, database.NewID()); err != nil {
+						return "", nil, nil, err
+					}
+				}
+			}
+			if f.AutoCreateTime > 0 || f.AutoUpdateTime > 0 {
+				if _, zero := f.ValueOf(ctx, rv); zero {
+					if err := f.Set(ctx, rv, now); err != nil {
+						return "", nil, nil, err
+					}
+				}
+			}
+			val, _ := f.ValueOf(ctx, rv)
+			// Tipos como gorm.DeletedAt se convertem sozinhos (driver.Valuer)
+			if valuer, ok := val.(driver.Valuer); ok {
+				v, err := valuer.Value()
+				if err != nil {
+					return "", nil, nil, fmt.Errorf("coluna %s: %w", f.DBName, err)
+				}
+				val = v
+			}
+			row[j] = val
+		}
+		rows[i] = row
+	}
+	return stmt.Schema.Table, cols, rows, nil
+}
+
+// copyFromPostgres manda o lote pelo protocolo COPY do pgx, numa conexão tirada do pool do GORM
+func copyFromPostgres(ctx context.Context, db *gorm.DB, table string, cols []string, rows [][]any) (int64, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	var copied int64
+	err = conn.Raw(func(driverConn any) error {
+		pgConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return errors.New("conexão postgres não é pgx (COPY indisponível)")
+		}
+		// "schema.tabela" vira o identificador com as duas partes
+		ident := pgx.Identifier(strings.Split(table, "."))
+		n, err := pgConn.Conn().CopyFrom(ctx, ident, cols, pgx.CopyFromRows(rows))
+		copied = n
+		return err
+	})
+	return copied, err
+}
+
+// copyInSQLServer usa o bulk copy do go-mssqldb: cada Exec só acumula a linha,
+// o Exec final sem argumentos manda tudo e devolve quantas entraram
+func copyInSQLServer(ctx context.Context, db *gorm.DB, table string, cols []string, rows [][]any) (int64, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// CheckConstraints/FireTriggers: a carga em massa se comporta como o INSERT normal na tabela
+	stmt, err := tx.PrepareContext(ctx, mssql.CopyIn(table, mssql.BulkOptions{CheckConstraints: true, FireTriggers: true}, cols...))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return 0, err
+		}
+	}
+	res, err := stmt.ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// batchClickHouse usa o modo batch do clickhouse-go: dentro da transação, o INSERT preparado
+// acumula as linhas no cliente e o Commit manda o bloco inteiro de uma vez (protocolo nativo).
+// O ClickHouse prefere poucos INSERTs grandes a muitos pequenos (cada INSERT vira uma "part").
+func batchClickHouse(ctx context.Context, db *gorm.DB, table string, cols []string, rows [][]any) (int64, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return 0, err
+	}
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("INSERT INTO %s (%s)", table, strings.Join(cols, ", ")))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int64(len(rows)), nil
+}
+
+func (r *Repository[T]) copyThreshold() int {
+	if r.CopyThreshold > 0 {
+		return r.CopyThreshold
+	}
+	return DefaultCopyThreshold
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/botlorien/go-rpa-template/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type ExecutionRepository struct {
+	DB *gorm.DB
+}
+
+// NewExecutionRepository espera a tabela já criada pelas migrações (ver internal/migrations)
+func NewExecutionRepository(db *gorm.DB) *ExecutionRepository {
+	return &ExecutionRepository{DB: db}
+}
+
+// Start registra o início de uma execução (RunID, robô, tenant, trigger e input já preenchidos por quem chama)
+func (r *ExecutionRepository) Start(exec *domain.Execution) error {
+	exec.Status = domain.ExecutionRunning
+	exec.StartedAt = time.Now()
+	if err := r.forTenant(exec).Create(exec).Error; err != nil {
+		return fmt.Errorf("erro ao registrar execução: %w", err)
+	}
+	return nil
+}
+
+// Finish grava o status final da execução, junto com ResultSummary e Artifacts já preenchidos.
+// Usa o DB sem o contexto da execução: se ela foi cancelada no shutdown, ainda precisamos gravar.
+func (r *ExecutionRepository) Finish(exec *domain.Execution, status string, execErr error) error {
+	now := time.Now()
+	exec.Status = status
+	exec.FinishedAt = &now
+	if execErr != nil {
+		exec.Error = execErr.Error()
+	}
+
+	// Select em vez de map: o map pula os serializers do GORM (result_summary/artifacts são JSON)
+	err := r.forTenant(exec).Model(exec).Select("status", "finished_at", "error", "result_summary", "artifacts").Updates(exec).Error
+	if err != nil {
+		return fmt.Errorf("erro ao finalizar execução %s: %w", exec.RunID, err)
+	}
+	return nil
+}
+
+// forTenant é o DB no tenant da própria execução (sem o ctx dela: ver Finish)
+func (r *ExecutionRepository) forTenant(exec *domain.Execution) *gorm.DB {
+	return r.DB.WithContext(WithTenant(context.Background(), exec.TenantID))
+}
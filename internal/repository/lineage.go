@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/botlorien/go-rpa-template/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// lineageField é o campo que liga a linha à execução que a gravou (coluna run_id)
+const lineageField = "RunID"
+
+type runIDKey struct{}
+
+// WithRunID anexa o ID da execução ao ctx. Tudo que os repositórios gravarem com esse ctx
+// sai com run_id preenchido (o robot.Service faz isso no começo do Execute).
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, runID)
+}
+
+// RunIDFrom lê o ID da execução do ctx ("" fora de uma execução, ex: scripts de manutenção)
+func RunIDFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	runID, _ := ctx.Value(runIDKey{}).(string)
+	return runID
+}
+
+// RegisterLineage instala o callback que carimba o run_id do ctx nas linhas criadas.
+// Vale para qualquer model com campo RunID e qualquer caminho de INSERT do GORM
+// (Create, CreateInBatches, Upsert); o COPY do BulkInsert carimba por conta própria.
+func RegisterLineage(db *gorm.DB) error {
+	return db.Callback().Create().Before("gorm:create").Register("lineage:run_id", stampRunID)
+}
+
+func stampRunID(db *gorm.DB) {
+	if db.Statement.Schema == nil {
+		return
+	}
+	field := db.Statement.Schema.LookUpField(lineageField)
+	runID := RunIDFrom(db.Statement.Context)
+	if field == nil || runID == "" {
+		return
+	}
+
+	ctx := db.Statement.Context
+	stamp := func(rv reflect.Value) {
+		// Linha que já veio com run_id (ex: a própria Execution) fica como está
+		if _, zero := field.ValueOf(ctx, rv); zero {
+			_ = field.Set(ctx, rv, runID)
+		}
+	}
+
+	switch rv := reflect.Indirect(db.Statement.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if elem := reflect.Indirect(rv.Index(i)); elem.Kind() == reflect.Struct {
+				stamp(elem)
+			}
+		}
+	case reflect.Struct:
+		stamp(rv)
+	}
+}
+
+// lineageAssignment faz o Upsert passar a linha atualizada para a execução atual
+// (quando as colunas do update são explícitas; no UpdateAll o run_id já vai junto)
+func (r *Repository[T]) lineageAssignment(ctx context.Context) (clause.Assignment, bool) {
+	runID := RunIDFrom(ctx)
+	if runID == "" || !r.hasField(lineageField) {
+		return clause.Assignment{}, false
+	}
+	return clause.Assignment{Column: clause.Column{Name: "run_id"}, Value: runID}, true
+}
+
+// snapshotConflicts guarda (RowSnapshot) as linhas que o Upsert vai sobrescrever, para o rollback.
+// tuples são os valores das conflictKeys de cada linha do lote (ver dedupeByKey).
+// Só roda dentro de uma execução; linhas que já são da própria execução não precisam de snapshot.
+func (r *Repository[T]) snapshotConflicts(tx *gorm.DB, conflictKeys []string, tuples [][]any) error {
+	runID := RunIDFrom(tx.Statement.Context)
+	if runID == "" || !r.hasField(lineageField) {
+		return nil
+	}
+
+	// (a, b) IN ((?, ?), ...) em lotes, igual o CreateInBatches
+	for start := 0; start < len(tuples); start += r.batchSize() {
+		end := min(start+r.batchSize(), len(tuples))
+		var existing []T
+		err := tx.Unscoped().
+			Where(tupleIn(tx, conflictKeys, tuples[start:end])).
+			Where("run_id IS NULL OR run_id <> ?", runID).
+			Find(&existing).Error
+		if err != nil {
+			return err
+		}
+		if err := r.saveSnapshots(tx, runID, domain.SnapshotUpdate, existing); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tupleIn monta o filtro (a, b) IN ((?, ?), ...). O SQL Server não aceita tupla no IN:
+// lá vira (a = ? AND b = ?) OR (a = ? AND b = ?) ...
+func tupleIn(tx *gorm.DB, keys []string, tuples [][]any) clause.Expression {
+	if tx.Dialector.Name() != "sqlserver" || len(keys) == 1 {
+		return clause.Expr{SQL: fmt.Sprintf("(%s) IN ?", strings.Join(keys, ", ")), Vars: []any{tuples}}
+	}
+	ors := make([]clause.Expression, len(tuples))
+	for i, tuple := range tuples {
+		ands := make([]clause.Expression, len(keys))
+		for j, key := range keys {
+			ands[j] = clause.Eq{Column: clause.Column{Name: key}, Value: tuple[j]}
+		}
+		ors[i] = clause.And(ands...)
+	}
+	return clause.Or(ors...)
+}
+
+// snapshotRange guarda as linhas que o ReplacePartition vai apagar do período
+func (r *Repository[T]) snapshotRange(tx *gorm.DB, dateColumn string, from, to time.Time) error {
+	runID := RunIDFrom(tx.Statement.Context)
+	if runID == "" || !r.hasField(lineageField) {
+		return nil
+	}
+
+	var existing []T
+	err := tx.Unscoped().
+		Where(clause.Gte{Column: clause.Column{Name: dateColumn}, Value: from}).
+		Where(clause.Lt{Column: clause.Column{Name: dateColumn}, Value: to}).
+		Where("run_id IS NULL OR run_id <> ?", runID).
+		Find(&existing).Error
+	if err != nil {
+		return err
+	}
+	return r.saveSnapshots(tx, runID, domain.SnapshotDelete, existing)
+}
+
+func (r *Repository[T]) saveSnapshots(tx *gorm.DB, runID, op string, rows []T) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	sch, err := r.schema()
+	if err != nil {
+		return err
+	}
+	pk := sch.PrioritizedPrimaryField
+	if pk == nil {
+		return fmt.Errorf("%s sem chave primária: não dá para guardar snapshot", sch.Table)
+	}
+
+	ctx := tx.Statement.Context
+	snaps := make([]domain.RowSnapshot, len(rows))
+	for i := range rows {
+		data, err := json.Marshal(rows[i])
+		if err != nil {
+			return fmt.Errorf("snapshot de %s: %w", sch.Table, err)
+		}
+		id, _ := pk.ValueOf(ctx, reflect.ValueOf(&rows[i]).Elem())
+		snaps[i] = domain.RowSnapshot{
+			RunID: runID,
+			Table: sch.Table,
+			RowID: fmt.Sprint(id),
+			Op:    op,
+			Data:  string(data),
+		}
+	}
+	// A mesma linha escrita duas vezes na execução fica com o primeiro snapshot (o estado original)
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(snaps, r.batchSize()).Error
+}
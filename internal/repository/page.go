@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/clause"
+)
+
+// Erros de parâmetro da listagem (o handler traduz para 400)
+var (
+	ErrInvalidCursor = errors.New("cursor inválido")
+	ErrInvalidSort   = errors.New("ordenação inválida")
+)
+
+// PageQuery descreve uma página da listagem por cursor (keyset).
+// O cursor guarda o último valor da coluna de ordenação + o ID, então a página seguinte
+// começa exatamente depois da última linha lida, mesmo com inserções no meio do caminho
+// (OFFSET pularia ou repetiria linhas e fica lento em tabela grande).
+type PageQuery struct {
+	Where  []clause.Expression // Filtros já montados pelo repositório específico
+	Sort   string              // Coluna (nome no banco); vazio = chave primária
+	Desc   bool
+	Cursor string // NextCursor da página anterior; vazio = primeira página
+	Limit  int
+}
+
+// Page é uma página da listagem. NextCursor vazio = acabou.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// pageCursor é o conteúdo do cursor (base64 de JSON: opaco para quem consome a API)
+type pageCursor struct {
+	Sort  string          `json:"s"`
+	Desc  bool            `json:"d,omitempty"`
+	Value json.RawMessage `json:"v"`
+	ID    json.RawMessage `json:"id"`
+}
+
+// Page lê uma página ordenada por q.Sort, desempatando pela chave primária
+func (r *Repository[T]) Page(ctx context.Context, q PageQuery) (Page[T], error) {
+	var page Page[T]
+	if q.Limit <= 0 {
+		return page, errors.New("limite da página deve ser maior que zero")
+	}
+	sch, err := r.schema()
+	if err != nil {
+		return page, err
+	}
+	pk := sch.PrioritizedPrimaryField
+	if pk == nil {
+		return page, fmt.Errorf("%s sem chave primária: não dá para paginar por cursor", sch.Table)
+	}
+	sortField := pk
+	if q.Sort != "" {
+		if sortField = sch.LookUpField(q.Sort); sortField == nil || sortField.DBName == "" {
+			return page, fmt.Errorf("%w: %s", ErrInvalidSort, q.Sort)
+		}
+	}
+
+	db := r.DB.WithContext(ctx).Model(new(T))
+	for _, where := range q.Where {
+		db = db.Where(where)
+	}
+
+	if q.Cursor != "" {
+		cur, err := decodeCursor(q.Cursor)
+		if err != nil || cur.Sort != sortField.DBName || cur.Desc != q.Desc {
+			return page, ErrInvalidCursor
+		}
+		// Os valores voltam com o tipo do campo (time.Time, float64...) para comparar direito no banco
+		last := reflect.New(sortField.FieldType)
+		lastID := reflect.New(pk.FieldType)
+		if json.Unmarshal(cur.Value, last.Interface()) != nil || json.Unmarshal(cur.ID, lastID.Interface()) != nil {
+			return page, ErrInvalidCursor
+		}
+		op := ">"
+		if q.Desc {
+			op = "<"
+		}
+		if sortField == pk {
+			db = db.Where(fmt.Sprintf("%s %s ?", pk.DBName, op), lastID.Elem().Interface())
+		} else {
+			db = db.Where(fmt.Sprintf("(%[1]s %[3]s ? OR (%[1]s = ? AND %[2]s %[3]s ?))", sortField.DBName, pk.DBName, op),
+				last.Elem().Interface(), last.Elem().Interface(), lastID.Elem().Interface())
+		}
+	}
+
+	db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: sortField.DBName}, Desc: q.Desc})
+	if sortField != pk {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: pk.DBName}, Desc: q.Desc})
+	}
+
+	// Uma linha a mais só para saber se existe próxima página
+	if err := db.Limit(q.Limit + 1).Find(&page.Items).Error; err != nil {
+		return page, fmt.Errorf("erro ao listar %s: %w", r.table(), err)
+	}
+	if len(page.Items) <= q.Limit {
+		return page, nil
+	}
+	page.Items = page.Items[:q.Limit]
+
+	rv := reflect.ValueOf(&page.Items[q.Limit-1]).Elem()
+	lastVal, _ := sortField.ValueOf(ctx, rv)
+	lastID, _ := pk.ValueOf(ctx, rv)
+	page.NextCursor, err = encodeCursor(pageCursor{Sort: sortField.DBName, Desc: q.Desc}, lastVal, lastID)
+	return page, err
+}
+
+func encodeCursor(cur pageCursor, value, id any) (string, error) {
+	var err error
+	if cur.Value, err = json.Marshal(value); err != nil {
+		return "", err
+	}
+	if cur.ID, err = json.Marshal(id); err != nil {
+		return "", err
+	}
+	raw, err := json.Marshal(cur)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	var cur pageCursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cur, err
+	}
+	err = json.Unmarshal(raw, &cur)
+	return cur, err
+}
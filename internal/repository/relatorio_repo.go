@@ -1,34 +1,106 @@
 package repository
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"time"
+
 	"github.com/botlorien/go-rpa-template/internal/domain"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// relatorioSortable são as colunas por onde a listagem pode ser ordenada
+var relatorioSortable = []string{"data_processamento", "destino", "peso_calculo_total", "id"}
+
+// relatorioNaturalKey identifica uma linha do relatório: um destino por data de processamento
+var relatorioNaturalKey = []string{"destino", "data_processamento"}
+
 type RelatorioRepository struct {
-	DB *gorm.DB
+	*Repository[domain.RelatorioPeso]
 }
 
+// NewRelatorioRepository espera a tabela já criada pelas migrações (ver internal/migrations)
 func NewRelatorioRepository(db *gorm.DB) *RelatorioRepository {
-	// Garante que a tabela existe
-	db.AutoMigrate(&domain.RelatorioPeso{})
-	return &RelatorioRepository{DB: db}
+	return &RelatorioRepository{Repository: New[domain.RelatorioPeso](db)}
 }
 
-func (r *RelatorioRepository) SaveBatch(dados []domain.RelatorioPeso) error {
-	if len(dados) == 0 {
-		return nil
+// SaveBatch grava o lote pela chave natural: rodar o robô de novo para o mesmo dia
+// atualiza o peso em vez de duplicar as linhas.
+//
+// referencia é a data do relatório (ex: a data_inicio do input). Ela vai para as linhas
+// sem DataProcessamento, que é o normal vindo do processor.Decode: com a data zerada,
+// toda execução cairia na mesma chave (destino, 0001-01-01) e sobrescreveria a anterior.
+func (r *RelatorioRepository) SaveBatch(ctx context.Context, referencia time.Time, dados []domain.RelatorioPeso) (UpsertResult, error) {
+	for i := range dados {
+		if !dados[i].DataProcessamento.IsZero() {
+			continue
+		}
+		if referencia.IsZero() {
+			return UpsertResult{}, fmt.Errorf("erro ao salvar lote: linha %d sem data de processamento e sem data de referência", i+1)
+		}
+		dados[i].DataProcessamento = referencia
+	}
+
+	res, err := r.Upsert(ctx, dados, relatorioNaturalKey, []string{"peso_calculo_total"})
+	if err != nil {
+		return res, fmt.Errorf("erro ao salvar lote: %w", err)
 	}
 
-	// CreateInBatches é ideal para grandes volumes (ETL)
-	result := r.DB.CreateInBatches(dados, 100)
-	
-	if result.Error != nil {
-		return fmt.Errorf("erro ao salvar lote: %w", result.Error)
+	fmt.Printf("💾 [Repo] %d registros novos, %d atualizados.\n", res.Inserted, res.Updated)
+	return res, nil
+}
+
+// ReplacePeriodo recarrega um período inteiro (ex: o relatório do mês foi reemitido e linhas sumiram)
+func (r *RelatorioRepository) ReplacePeriodo(ctx context.Context, inicio, fim time.Time, dados []domain.RelatorioPeso) (ReplaceResult, error) {
+	// Linha fora do período ficaria fora da próxima recarga também (ex: data zerada do Decode)
+	for i, d := range dados {
+		if d.DataProcessamento.Before(inicio) || !d.DataProcessamento.Before(fim) {
+			return ReplaceResult{}, fmt.Errorf("erro ao recarregar período: linha %d com data %s fora de %s a %s", i+1,
+				d.DataProcessamento.Format("02/01/2006"), inicio.Format("02/01/2006"), fim.Format("02/01/2006"))
+		}
+	}
+	res, err := r.ReplacePartition(ctx, "data_processamento", inicio, fim, dados)
+	if err != nil {
+		return res, err
 	}
 
-	fmt.Printf("💾 [Repo] %d registros salvos com sucesso.\n", result.RowsAffected)
-	return nil
-}
\ No newline at end of file
+	fmt.Printf("💾 [Repo] Período %s a %s recarregado: %d removidos, %d inseridos.\n",
+		inicio.Format("02/01/2006"), fim.Format("02/01/2006"), res.Deleted, res.Inserted)
+	return res, nil
+}
+
+// RelatorioFilter são os filtros da listagem (API de leitura)
+type RelatorioFilter struct {
+	Destino string
+	From    time.Time // Data de processamento >= From (zero = sem limite)
+	To      time.Time // Data de processamento < To (zero = sem limite)
+	Sort    string    // Uma das colunas de relatorioSortable; vazio = data_processamento
+	Desc    bool
+	Cursor  string
+	Limit   int
+}
+
+// List devolve uma página do relatório filtrada e ordenada (paginação por cursor)
+func (r *RelatorioRepository) List(ctx context.Context, f RelatorioFilter) (Page[domain.RelatorioPeso], error) {
+	q := PageQuery{Sort: f.Sort, Desc: f.Desc, Cursor: f.Cursor, Limit: f.Limit}
+	if q.Sort == "" {
+		q.Sort = "data_processamento"
+	}
+	if !contains(relatorioSortable, q.Sort) {
+		return Page[domain.RelatorioPeso]{}, fmt.Errorf("%w: %s (use %s)", ErrInvalidSort, q.Sort, strings.Join(relatorioSortable, ", "))
+	}
+
+	if f.Destino != "" {
+		q.Where = append(q.Where, clause.Eq{Column: clause.Column{Name: "destino"}, Value: f.Destino})
+	}
+	if !f.From.IsZero() {
+		q.Where = append(q.Where, clause.Gte{Column: clause.Column{Name: "data_processamento"}, Value: f.From})
+	}
+	if !f.To.IsZero() {
+		q.Where = append(q.Where, clause.Lt{Column: clause.Column{Name: "data_processamento"}, Value: f.To})
+	}
+	return r.Page(ctx, q)
+}
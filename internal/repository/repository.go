@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/botlorien/go-rpa-template/pkg/metrics"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DefaultBatchSize é o tamanho dos lotes de INSERT (mesmo valor que o SaveBatch sempre usou)
+const DefaultBatchSize = 100
+
+// UpsertResult conta o que o Upsert fez com o lote
+type UpsertResult struct {
+	Inserted int64
+	Updated  int64
+}
+
+// ReplaceResult conta o que o ReplacePartition fez com o período
+type ReplaceResult struct {
+	Deleted  int64
+	Inserted int64
+}
+
+// Repository[T] é a base genérica dos repositórios de carga (ETL).
+// Os repositórios específicos embutem um Repository[Model] e ganham Upsert/BulkInsert/DeleteWhere/ReplacePartition.
+type Repository[T any] struct {
+	DB            *gorm.DB
+	BatchSize     int
+	CopyThreshold int // Lote mínimo para o BulkInsert usar a carga em massa do banco (COPY, bulk copy...)
+}
+
+// New cria o repositório genérico de um model
+func New[T any](db *gorm.DB) *Repository[T] {
+	return &Repository[T]{DB: db, BatchSize: DefaultBatchSize, CopyThreshold: DefaultCopyThreshold}
+}
+
+// Upsert insere o lote e, quando a chave natural (conflictKeys) já existe, atualiza updateCols.
+// Sem updateCols, atualiza todas as colunas menos a chave primária e o created_at.
+// Rodar o robô duas vezes para o mesmo dia atualiza as linhas em vez de duplicá-las.
+//
+// Precisa de um índice único nas conflictKeys. No MySQL (ON DUPLICATE KEY) vale qualquer
+// índice único da tabela; conflictKeys é usado no postgres, no sqlite e no SQL Server (MERGE).
+// Em model com TenantID, o tenant_id entra sozinho na frente das conflictKeys.
+// O ClickHouse não tem upsert nem índice único: lá as linhas existentes são lidas pela chave,
+// apagadas e regravadas com o mesmo ID (ver upsertClickHouse).
+//
+// Linhas do lote com a mesma chave natural viram uma só (a última ganha): o postgres e o MERGE
+// do SQL Server recusam o lote inteiro se o upsert atualizar a mesma linha duas vezes.
+//
+// Atualizados = linhas do lote cuja chave já existia na tabela (SELECT pelas chaves do lote,
+// na mesma transação; o RowsAffected muda de significado entre os bancos). Só uma escrita
+// concorrente nas mesmas chaves pode distorcer a contagem, nunca os dados.
+func (r *Repository[T]) Upsert(ctx context.Context, batch []T, conflictKeys, updateCols []string) (UpsertResult, error) {
+	var res UpsertResult
+	if len(batch) == 0 {
+		return res, nil
+	}
+	if len(conflictKeys) == 0 {
+		return res, errors.New("upsert sem colunas de conflito (chave natural)")
+	}
+
+	// Model com tenant: a chave natural vale dentro do tenant (o índice único começa pelo tenant_id),
+	// senão a carga de uma filial sobrescreveria a linha de outra com o mesmo destino/data
+	if r.hasField(tenantField) && !contains(conflictKeys, "tenant_id") {
+		conflictKeys = append([]string{"tenant_id"}, conflictKeys...)
+	}
+	if err := r.stampTenant(ctx, batch); err != nil {
+		return res, fmt.Errorf("erro no upsert de %s: %w", r.table(), err)
+	}
+	keys, err := r.fields(conflictKeys)
+	if err != nil {
+		return res, fmt.Errorf("erro no upsert de %s: %w", r.table(), err)
+	}
+	batch, tuples := dedupeByKey(ctx, batch, keys)
+
+	if r.DB.Dialector.Name() == "clickhouse" {
+		res, err := r.upsertClickHouse(ctx, batch, conflictKeys, updateCols, keys, tuples)
+		if err != nil {
+			return UpsertResult{}, fmt.Errorf("erro no upsert de %s: %w", r.table(), err)
+		}
+		metrics.RowsSaved.WithLabelValues(r.table()).Add(float64(len(batch)))
+		return res, nil
+	}
+
+	onConflict := clause.OnConflict{Columns: columns(conflictKeys)}
+	if len(updateCols) == 0 {
+		onConflict.UpdateAll = true
+	} else {
+		assignments := clause.AssignmentColumns(updateCols)
+		// Uma linha apagada (soft delete) que volta no relatório precisa reaparecer
+		if r.hasField("DeletedAt") && !contains(updateCols, "deleted_at") {
+			assignments = append(assignments, clause.Assignment{Column: clause.Column{Name: "deleted_at"}, Value: nil})
+		}
+		if r.hasField("UpdatedAt") && !contains(updateCols, "updated_at") {
+			assignments = append(assignments, clause.Assignment{Column: clause.Column{Name: "updated_at"}, Value: time.Now()})
+		}
+		if lineage, ok := r.lineageAssignment(ctx); ok && !contains(updateCols, "run_id") {
+			assignments = append(assignments, lineage)
+		}
+		onConflict.DoUpdates = assignments
+	}
+
+	err = r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existing, err := r.countExisting(tx, conflictKeys, tuples)
+		if err != nil {
+			return err
+		}
+		// Guarda como estavam as linhas que vão ser sobrescritas (rollback da execução)
+		if err := r.snapshotConflicts(tx, conflictKeys, tuples); err != nil {
+			return err
+		}
+		if err := tx.Clauses(onConflict).CreateInBatches(batch, r.batchSize()).Error; err != nil {
+			return err
+		}
+		res.Updated = existing
+		res.Inserted = int64(len(batch)) - existing
+		return nil
+	})
+	if err != nil {
+		return UpsertResult{}, fmt.Errorf("erro no upsert de %s: %w", r.table(), err)
+	}
+
+	metrics.RowsSaved.WithLabelValues(r.table()).Add(float64(len(batch)))
+	return res, nil
+}
+
+// countExisting conta quantas chaves do lote já têm linha na tabela (inclusive soft-deleted,
+// que o upsert traz de volta), em lotes do tamanho do INSERT
+func (r *Repository[T]) countExisting(tx *gorm.DB, conflictKeys []string, tuples [][]any) (int64, error) {
+	var total int64
+	for start := 0; start < len(tuples); start += r.batchSize() {
+		end := min(start+r.batchSize(), len(tuples))
+		var n int64
+		if err := tx.Model(new(T)).Unscoped().Where(tupleIn(tx, conflictKeys, tuples[start:end])).Count(&n).Error; err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// dedupeByKey junta as linhas com a mesma chave (a última ganha, na posição da primeira)
+// e devolve os valores da chave de cada linha. Sem repetição o lote volta o mesmo slice,
+// e os IDs gerados no INSERT continuam chegando em quem chamou.
+func dedupeByKey[T any](ctx context.Context, batch []T, keys []*schema.Field) ([]T, [][]any) {
+	tuples := make([][]any, 0, len(batch))
+	index := make(map[string]int, len(batch))
+	var out []T // Só é criado na primeira repetição
+	for i := range batch {
+		rv := reflect.ValueOf(&batch[i]).Elem()
+		tuple := make([]any, len(keys))
+		for j, f := range keys {
+			tuple[j], _ = f.ValueOf(ctx, rv)
+		}
+		key := keyString(tuple)
+		if pos, dup := index[key]; dup {
+			if out == nil {
+				out = append([]T(nil), batch[:i]...)
+			}
+			out[pos] = batch[i]
+			continue
+		}
+		index[key] = len(tuples)
+		tuples = append(tuples, tuple)
+		if out != nil {
+			out = append(out, batch[i])
+		}
+	}
+	if out == nil {
+		return batch, tuples
+	}
+	return out, tuples
+}
+
+// keyString identifica a chave natural de uma linha. Datas pelo instante, em UTC:
+// o mesmo dia lido em fusos diferentes é a mesma chave no banco.
+func keyString(tuple []any) string {
+	var b strings.Builder
+	for _, v := range tuple {
+		switch t := v.(type) {
+		case time.Time:
+			v = t.UTC().Format(time.RFC3339Nano)
+		case *time.Time:
+			if t != nil {
+				v = t.UTC().Format(time.RFC3339Nano)
+			}
+		}
+		fmt.Fprintf(&b, "%T=%v\x00", v, v)
+	}
+	return b.String()
+}
+
+// DeleteWhere apaga as linhas que casam com a condição (soft delete se o model tiver DeletedAt).
+// Condição vazia é recusada pelo GORM (gorm.ErrMissingWhereClause): não há como apagar a tabela toda por engano.
+func (r *Repository[T]) DeleteWhere(ctx context.Context, query any, args ...any) (int64, error) {
+	result := r.DB.WithContext(ctx).Where(query, args...).Delete(new(T))
+	if result.Error != nil {
+		return 0, fmt.Errorf("erro ao apagar de %s: %w", r.table(), result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// ReplacePartition troca os dados de um período inteiro pelo lote ("recarga idempotente"):
+// apaga de verdade as linhas com dateColumn em [from, to) e insere o lote, na mesma transação.
+// Serve para relatórios em que linhas podem sumir entre uma extração e outra (o Upsert não as removeria).
+func (r *Repository[T]) ReplacePartition(ctx context.Context, dateColumn string, from, to time.Time, batch []T) (ReplaceResult, error) {
+	var res ReplaceResult
+	if !from.Before(to) {
+		return res, fmt.Errorf("período inválido: %s a %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.snapshotRange(tx, dateColumn, from, to); err != nil {
+			return err
+		}
+
+		// Unscoped: linhas soft-deleted do período também saem, senão travariam o índice único
+		deleted := tx.Unscoped().
+			Where(clause.Gte{Column: clause.Column{Name: dateColumn}, Value: from}).
+			Where(clause.Lt{Column: clause.Column{Name: dateColumn}, Value: to}).
+			Delete(new(T))
+		if deleted.Error != nil {
+			return deleted.Error
+		}
+		res.Deleted = deleted.RowsAffected
+
+		if len(batch) == 0 {
+			return nil
+		}
+		inserted := tx.CreateInBatches(batch, r.batchSize())
+		if inserted.Error != nil {
+			return inserted.Error
+		}
+		res.Inserted = inserted.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return ReplaceResult{}, fmt.Errorf("erro ao recarregar período de %s: %w", r.table(), err)
+	}
+
+	metrics.RowsSaved.WithLabelValues(r.table()).Add(float64(res.Inserted))
+	return res, nil
+}
+
+func (r *Repository[T]) batchSize() int {
+	if r.BatchSize > 0 {
+		return r.BatchSize
+	}
+	return DefaultBatchSize
+}
+
+// table é o nome da tabela do model (usado nas mensagens e no label das métricas)
+func (r *Repository[T]) table() string {
+	sch, err := r.schema()
+	if err != nil {
+		return fmt.Sprintf("%T", *new(T))
+	}
+	return sch.Table
+}
+
+// fields acha os campos do model pelas colunas (ex: as conflictKeys do Upsert)
+func (r *Repository[T]) fields(names []string) ([]*schema.Field, error) {
+	sch, err := r.schema()
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]*schema.Field, len(names))
+	for i, name := range names {
+		if fields[i] = sch.LookUpField(name); fields[i] == nil {
+			return nil, fmt.Errorf("coluna de conflito %s não existe em %s", name, sch.Table)
+		}
+	}
+	return fields, nil
+}
+
+func (r *Repository[T]) hasField(name string) bool {
+	sch, err := r.schema()
+	if err != nil {
+		return false
+	}
+	return sch.LookUpField(name) != nil
+}
+
+// schema é o schema do model parseado pelo GORM (fica em cache no próprio GORM)
+func (r *Repository[T]) schema() (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: r.DB}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+func columns(names []string) []clause.Column {
+	cols := make([]clause.Column, len(names))
+	for i, name := range names {
+		cols[i] = clause.Column{Name: name}
+	}
+	return cols
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/botlorien/go-rpa-template/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Purger é um repositório que sabe aplicar a política de retenção na sua tabela.
+// Todo Repository[T] serve.
+type Purger interface {
+	PurgeBefore(ctx context.Context, column string, before time.Time, dryRun bool) (int64, error)
+	PurgeSoftDeleted(ctx context.Context, before time.Time, dryRun bool) (int64, error)
+}
+
+// RetentionTarget liga uma tabela à coluna de data que define a idade das linhas
+type RetentionTarget struct {
+	Table  string
+	Column string
+	Purger Purger
+}
+
+// PurgeBefore apaga de verdade (inclusive as soft-deleted) as linhas com column anterior a before.
+// Com dryRun só conta quantas seriam apagadas.
+func (r *Repository[T]) PurgeBefore(ctx context.Context, column string, before time.Time, dryRun bool) (int64, error) {
+	if !r.hasField(column) {
+		return 0, fmt.Errorf("%s não tem a coluna %s", r.table(), column)
+	}
+	return r.purge(ctx, clause.Lt{Column: clause.Column{Name: column}, Value: before}, dryRun)
+}
+
+// PurgeSoftDeleted apaga de verdade as linhas soft-deleted há mais tempo que before.
+// Models sem DeletedAt não têm o que limpar.
+func (r *Repository[T]) PurgeSoftDeleted(ctx context.Context, before time.Time, dryRun bool) (int64, error) {
+	if !r.hasField("DeletedAt") {
+		return 0, nil
+	}
+	return r.purge(ctx, clause.Lt{Column: clause.Column{Name: "deleted_at"}, Value: before}, dryRun)
+}
+
+// SnapshotPurger é a retenção do row_snapshots: só sai o snapshot de execução que não pode mais
+// ser desfeita (já desfeita ou já expurgada do executions). O de uma execução que ainda pode
+// ter rollback fica, por mais velho que seja: sem ele o rollback não devolveria as linhas
+// que ela sobrescreveu ou apagou.
+type SnapshotPurger struct {
+	*Repository[domain.RowSnapshot]
+}
+
+// NewSnapshotPurger cria a retenção do row_snapshots
+func NewSnapshotPurger(db *gorm.DB) SnapshotPurger {
+	return SnapshotPurger{Repository: New[domain.RowSnapshot](db)}
+}
+
+// PurgeBefore apaga os snapshots anteriores a before cujas execuções não podem mais ser desfeitas
+func (p SnapshotPurger) PurgeBefore(ctx context.Context, column string, before time.Time, dryRun bool) (int64, error) {
+	if !p.hasField(column) {
+		return 0, fmt.Errorf("%s não tem a coluna %s", p.table(), column)
+	}
+	executions, err := New[domain.Execution](p.DB).schema()
+	if err != nil {
+		return 0, err
+	}
+	rollbackable := clause.Expr{
+		SQL:  fmt.Sprintf("run_id NOT IN (SELECT run_id FROM %s WHERE status <> ?)", executions.Table),
+		Vars: []any{domain.ExecutionRolledBack},
+	}
+	return p.purge(ctx, clause.And(clause.Lt{Column: clause.Column{Name: column}, Value: before}, rollbackable), dryRun)
+}
+
+func (r *Repository[T]) purge(ctx context.Context, cond clause.Expression, dryRun bool) (int64, error) {
+	db := r.DB.WithContext(ctx).Unscoped().Where(cond)
+	if dryRun {
+		var n int64
+		if err := db.Model(new(T)).Count(&n).Error; err != nil {
+			return 0, fmt.Errorf("erro ao contar linhas antigas de %s: %w", r.table(), err)
+		}
+		return n, nil
+	}
+
+	result := db.Delete(new(T))
+	if result.Error != nil {
+		return 0, fmt.Errorf("erro ao apagar linhas antigas de %s: %w", r.table(), result.Error)
+	}
+	return result.RowsAffected, nil
+}
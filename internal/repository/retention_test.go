@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/botlorien/go-rpa-template/internal/domain"
+)
+
+// Snapshot de execução que ainda pode ter rollback não sai, por mais velho que seja
+func TestSnapshotPurgerKeepsRollbackableRuns(t *testing.T) {
+	db := newTestRepo(t).DB
+	executions := []domain.Execution{
+		{RunID: "ativa", Status: domain.ExecutionCompleted},
+		{RunID: "desfeita", Status: domain.ExecutionRolledBack},
+	}
+	if err := db.Create(&executions).Error; err != nil {
+		t.Fatal(err)
+	}
+	// "expurgada" não está mais no executions
+	for _, runID := range []string{"ativa", "desfeita", "expurgada"} {
+		snap := domain.RowSnapshot{RunID: runID, Table: "relatorio_pesos", RowID: "1", Op: domain.SnapshotUpdate, Data: "{}"}
+		if err := db.Create(&snap).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	purger := NewSnapshotPurger(db)
+	future := time.Now().Add(time.Hour)
+	n, err := purger.PurgeBefore(context.Background(), "created_at", future, true)
+	if err != nil || n != 2 {
+		t.Fatalf("dry-run contou %d (%v), esperado 2", n, err)
+	}
+	if n, err = purger.PurgeBefore(context.Background(), "created_at", future, false); err != nil || n != 2 {
+		t.Fatalf("apagou %d (%v), esperado 2", n, err)
+	}
+
+	var left []domain.RowSnapshot
+	if err := db.Find(&left).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 1 || left[0].RunID != "ativa" {
+		t.Errorf("sobraram %+v, esperado só o snapshot de \"ativa\"", left)
+	}
+}
@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/botlorien/go-rpa-template/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// Erros do rollback (o handler HTTP traduz para 404/409)
+var (
+	ErrRunNotFound     = errors.New("execução não encontrada")
+	ErrRunStillRunning = errors.New("execução ainda está rodando")
+	ErrRunRolledBack   = errors.New("execução já foi desfeita")
+)
+
+// rollbackRobot é o "robô" do registro que o próprio rollback deixa no histórico de execuções
+const rollbackRobot = "rollback"
+
+// Rollbacker é um repositório que sabe desfazer o que uma execução gravou na sua tabela.
+// Todo Repository[T] com campo RunID serve.
+type Rollbacker interface {
+	RollbackRun(tx *gorm.DB, run domain.Execution, hard bool) (RollbackStats, error)
+}
+
+// RollbackStats é o que o rollback fez numa tabela
+type RollbackStats struct {
+	Table     string `json:"table"`
+	Deleted   int64  `json:"deleted"`   // Linhas que a execução inseriu
+	Restored  int64  `json:"restored"`  // Linhas que ela sobrescreveu/apagou, devolvidas do snapshot
+	Conflicts int64  `json:"conflicts"` // Linhas alteradas por outra execução depois: ficam como estão
+	Kept      int64  `json:"kept"`      // Linhas que ela sobrescreveu sem snapshot: ficam como estão
+}
+
+// RollbackRequest é o pedido de rollback (CLI ou API)
+type RollbackRequest struct {
+	RunID         string
+	Hard          bool // Apaga de verdade as linhas inseridas (padrão: soft delete)
+	Force         bool // Desfaz mesmo com a execução "running" (processo morreu sem finalizar)
+	TriggerSource string
+	RequestedBy   string
+}
+
+// RollbackReport é o resultado do rollback, também gravado no histórico (ResultSummary)
+type RollbackReport struct {
+	RunID         string          `json:"run_id"`
+	RollbackRunID string          `json:"rollback_run_id"`
+	Tables        []RollbackStats `json:"tables"`
+}
+
+// Rollback desfaz a carga de uma execução em todas as tabelas com linhagem (run_id)
+type Rollback struct {
+	Executions *ExecutionRepository
+	Tables     []Rollbacker
+}
+
+// NewRollback monta o rollback com os repositórios que gravam dados das execuções
+func NewRollback(executions *ExecutionRepository, tables ...Rollbacker) *Rollback {
+	return &Rollback{Executions: executions, Tables: tables}
+}
+
+// Run desfaz a execução numa transação só: ou tudo volta, ou nada muda.
+// Só enxerga execuções do tenant do ctx (a de outro tenant dá ErrRunNotFound).
+// A execução original passa a "rolled_back" e o rollback vira uma Execution própria
+// (robot "rollback", com o relatório no ResultSummary), mesmo quando falha no meio.
+func (rb *Rollback) Run(ctx context.Context, req RollbackRequest) (RollbackReport, error) {
+	report := RollbackReport{RunID: req.RunID, RollbackRunID: uuid.NewString()}
+
+	record := &domain.Execution{
+		RunID:           report.RollbackRunID,
+		TenantID:        TenantFrom(ctx),
+		Robot:           rollbackRobot,
+		TriggerSource:   req.TriggerSource,
+		TriggerIdentity: req.RequestedBy,
+		Input:           map[string]any{"rollback_of": req.RunID, "hard": req.Hard, "force": req.Force},
+	}
+
+	attempted := false // Pedidos recusados (não existe, já desfeita...) não vão para o histórico
+	err := rb.Executions.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var original domain.Execution
+		if err := tx.Where("run_id = ?", req.RunID).Take(&original).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: %s", ErrRunNotFound, req.RunID)
+			}
+			return err
+		}
+		switch {
+		case original.Robot == rollbackRobot:
+			return fmt.Errorf("%s é um rollback, não uma carga", req.RunID)
+		case original.Status == domain.ExecutionRolledBack:
+			return fmt.Errorf("%w: %s", ErrRunRolledBack, req.RunID)
+		case original.Status == domain.ExecutionRunning && !req.Force:
+			return fmt.Errorf("%w: %s (use force se o processo morreu)", ErrRunStillRunning, req.RunID)
+		}
+		record.Input["robot"] = original.Robot
+		attempted = true
+
+		for _, table := range rb.Tables {
+			stats, err := table.RollbackRun(tx, original, req.Hard)
+			if err != nil {
+				return err
+			}
+			report.Tables = append(report.Tables, stats)
+		}
+
+		return tx.Model(&original).Update("status", domain.ExecutionRolledBack).Error
+	})
+
+	if !attempted {
+		return report, err
+	}
+
+	// Registra o rollback no histórico (sucesso ou falha), fora da transação que pode ter voltado
+	if startErr := rb.Executions.Start(record); startErr != nil {
+		log.Error().Err(startErr).Str("run_id", req.RunID).Msg("Falha ao registrar rollback no histórico")
+	} else {
+		record.ResultSummary = map[string]any{"tables": report.Tables}
+		status := domain.ExecutionCompleted
+		if err != nil {
+			status = domain.ExecutionFailed
+		}
+		if finishErr := rb.Executions.Finish(record, status, err); finishErr != nil {
+			log.Error().Err(finishErr).Str("run_id", req.RunID).Msg("Falha ao finalizar rollback no histórico")
+		}
+	}
+
+	return report, err
+}
+
+// RollbackRun desfaz o que a execução gravou nesta tabela (implementa Rollbacker):
+//  1. linhas que ela sobrescreveu/apagou voltam ao estado do snapshot, se ninguém mexeu depois;
+//  2. linhas que ela inseriu são apagadas (soft delete, ou de verdade com hard).
+//
+// "Inseriu" = run_id dela, sem snapshot e com created_at a partir do início da execução.
+// Linha com o run_id dela mas criada antes já existia e só foi sobrescrita: sem o snapshot
+// (apagado à mão, por exemplo) não há como devolvê-la, então fica como está (Kept).
+//
+// Se a execução apagou linhas (ReplacePartition), as inseridas saem de verdade sempre:
+// soft-deleted elas continuariam no índice único e impediriam a volta das antigas.
+func (r *Repository[T]) RollbackRun(tx *gorm.DB, run domain.Execution, hard bool) (RollbackStats, error) {
+	stats := RollbackStats{Table: r.table()}
+	runID := run.RunID
+	sch, err := r.schema()
+	if err != nil {
+		return stats, err
+	}
+	if sch.LookUpField(lineageField) == nil {
+		return stats, fmt.Errorf("%s não tem coluna run_id: sem rollback", sch.Table)
+	}
+	pk := sch.PrioritizedPrimaryField
+	ctx := tx.Statement.Context
+
+	var snaps []domain.RowSnapshot
+	if err := tx.Where("run_id = ? AND table_name = ?", runID, sch.Table).Order("id").Find(&snaps).Error; err != nil {
+		return stats, err
+	}
+
+	type restore struct {
+		op  string
+		row T
+		id  any
+	}
+	restores := make([]restore, 0, len(snaps))
+	var touched []any
+	for _, snap := range snaps {
+		var row T
+		if err := json.Unmarshal([]byte(snap.Data), &row); err != nil {
+			return stats, fmt.Errorf("snapshot %d de %s corrompido: %w", snap.ID, sch.Table, err)
+		}
+		id, _ := pk.ValueOf(ctx, reflect.ValueOf(&row).Elem())
+		restores = append(restores, restore{op: snap.Op, row: row, id: id})
+		if snap.Op == domain.SnapshotDelete {
+			hard = true
+		}
+		touched = append(touched, id)
+	}
+
+	// 2 antes de 1: as inseridas saem primeiro para não travar a volta das apagadas no índice único
+	untouched := func() *gorm.DB {
+		q := tx.Where("run_id = ?", runID)
+		if len(touched) > 0 {
+			q = q.Where(fmt.Sprintf("%s NOT IN ?", pk.DBName), touched)
+		}
+		return q
+	}
+	inserted := untouched
+	if sch.LookUpField("CreatedAt") != nil {
+		var kept int64
+		if err := untouched().Model(new(T)).Where("created_at < ?", run.StartedAt).Count(&kept).Error; err != nil {
+			return stats, err
+		}
+		stats.Kept = kept
+		inserted = func() *gorm.DB { return untouched().Where("created_at >= ?", run.StartedAt) }
+	}
+	del := func() *gorm.DB {
+		if hard {
+			return inserted().Unscoped()
+		}
+		return inserted()
+	}
+	if stats.Deleted, err = rollbackDeleted(tx, del, new(T)); err != nil {
+		return stats, err
+	}
+
+	for _, rs := range restores {
+		var current T
+		found := tx.Unscoped().Where(fmt.Sprintf("%s = ?", pk.DBName), rs.id).Limit(1).Find(&current)
+		if found.Error != nil {
+			return stats, found.Error
+		}
+
+		switch rs.op {
+		case domain.SnapshotUpdate:
+			// A linha tem que continuar sendo desta execução; senão outra carga já passou por cima
+			if found.RowsAffected == 0 || r.runIDOf(ctx, &current) != runID {
+				stats.Conflicts++
+				continue
+			}
+			// Updates com todas as colunas, não Save: no ClickHouse o UPDATE (mutation) volta 0 linhas
+			// afetadas e o Save cairia num INSERT, duplicando a linha
+			if err := tx.Unscoped().Model(&rs.row).Select("*").Updates(&rs.row).Error; err != nil {
+				return stats, err
+			}
+		case domain.SnapshotDelete:
+			if found.RowsAffected > 0 {
+				stats.Conflicts++
+				continue
+			}
+			if err := tx.Create(&rs.row).Error; err != nil {
+				return stats, err
+			}
+		}
+		stats.Restored++
+	}
+
+	if stats.Kept > 0 {
+		log.Warn().Str("table", sch.Table).Str("run_id", runID).Int64("linhas", stats.Kept).
+			Msg("Rollback: linhas sobrescritas pela execução sem snapshot ficaram como estão")
+	}
+	if stats.Conflicts > 0 {
+		log.Warn().Str("table", sch.Table).Str("run_id", runID).Int64("conflitos", stats.Conflicts).
+			Msg("Rollback: linhas alteradas por outra execução depois desta ficaram como estão")
+	}
+	return stats, nil
+}
+
+func (r *Repository[T]) runIDOf(ctx context.Context, row *T) string {
+	sch, err := r.schema()
+	if err != nil {
+		return ""
+	}
+	v, _ := sch.LookUpField(lineageField).ValueOf(ctx, reflect.ValueOf(row).Elem())
+	runID, _ := v.(string)
+	return runID
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/botlorien/go-rpa-template/internal/domain"
+	"github.com/botlorien/go-rpa-template/internal/migrations"
+	"github.com/botlorien/go-rpa-template/pkg/database"
+)
+
+var rollbackDay = time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+
+func newTestRepo(t *testing.T) *Repository[domain.RelatorioPeso] {
+	t.Helper()
+	db, err := database.NewConnection("sqlite", filepath.Join(t.TempDir(), "rpa.db"))
+	if err != nil {
+		t.Fatalf("conexão: %v", err)
+	}
+	t.Cleanup(func() { database.Close(db) })
+	if err := migrations.AutoMigrate(db); err != nil {
+		t.Fatalf("migração: %v", err)
+	}
+	if err := RegisterLineage(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterTenancy(db); err != nil {
+		t.Fatal(err)
+	}
+	return New[domain.RelatorioPeso](db)
+}
+
+func pesos(values map[string]float64) []domain.RelatorioPeso {
+	var batch []domain.RelatorioPeso
+	for destino, peso := range values {
+		batch = append(batch, domain.RelatorioPeso{Destino: destino, PesoCalculoTotal: peso, DataProcessamento: rollbackDay})
+	}
+	return batch
+}
+
+func TestRollbackRun(t *testing.T) {
+	upsert := func(runID string, values map[string]float64) func(*testing.T, *Repository[domain.RelatorioPeso]) {
+		return func(t *testing.T, r *Repository[domain.RelatorioPeso]) {
+			ctx := WithRunID(context.Background(), runID)
+			if _, err := r.Upsert(ctx, pesos(values), []string{"destino", "data_processamento"}, nil); err != nil {
+				t.Fatalf("upsert %s: %v", runID, err)
+			}
+		}
+	}
+	replace := func(runID string, values map[string]float64) func(*testing.T, *Repository[domain.RelatorioPeso]) {
+		return func(t *testing.T, r *Repository[domain.RelatorioPeso]) {
+			ctx := WithRunID(context.Background(), runID)
+			if _, err := r.ReplacePartition(ctx, "data_processamento", rollbackDay, rollbackDay.AddDate(0, 0, 1), pesos(values)); err != nil {
+				t.Fatalf("replace %s: %v", runID, err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name      string
+		loads     []func(*testing.T, *Repository[domain.RelatorioPeso]) // Depois da carga base (run-1: A=1, B=2)
+		hard      bool
+		purged    bool // Snapshots do run-2 apagados antes do rollback
+		want      RollbackStats
+		wantRows  map[string]float64 // Linhas visíveis depois do rollback do run-2
+		wantTotal int                // Linhas na tabela, inclusive soft-deleted
+	}{
+		{
+			name:      "inseridas saem com soft delete",
+			loads:     []func(*testing.T, *Repository[domain.RelatorioPeso]){upsert("run-2", map[string]float64{"C": 3})},
+			want:      RollbackStats{Deleted: 1},
+			wantRows:  map[string]float64{"A": 1, "B": 2},
+			wantTotal: 3,
+		},
+		{
+			name:      "hard apaga de verdade",
+			loads:     []func(*testing.T, *Repository[domain.RelatorioPeso]){upsert("run-2", map[string]float64{"C": 3})},
+			hard:      true,
+			want:      RollbackStats{Deleted: 1},
+			wantRows:  map[string]float64{"A": 1, "B": 2},
+			wantTotal: 2,
+		},
+		{
+			name:      "sobrescrita volta do snapshot",
+			loads:     []func(*testing.T, *Repository[domain.RelatorioPeso]){upsert("run-2", map[string]float64{"A": 10, "C": 3})},
+			want:      RollbackStats{Deleted: 1, Restored: 1},
+			wantRows:  map[string]float64{"A": 1, "B": 2},
+			wantTotal: 3,
+		},
+		{
+			name: "alterada depois por outra execução fica",
+			loads: []func(*testing.T, *Repository[domain.RelatorioPeso]){
+				upsert("run-2", map[string]float64{"A": 10}),
+				upsert("run-3", map[string]float64{"A": 20}),
+			},
+			want:      RollbackStats{Conflicts: 1},
+			wantRows:  map[string]float64{"A": 20, "B": 2},
+			wantTotal: 2,
+		},
+		{
+			name:      "recarga do período devolve as apagadas",
+			loads:     []func(*testing.T, *Repository[domain.RelatorioPeso]){replace("run-2", map[string]float64{"C": 3})},
+			want:      RollbackStats{Deleted: 1, Restored: 2},
+			wantRows:  map[string]float64{"A": 1, "B": 2},
+			wantTotal: 2,
+		},
+		{
+			name:      "sem snapshot não apaga as sobrescritas",
+			loads:     []func(*testing.T, *Repository[domain.RelatorioPeso]){upsert("run-2", map[string]float64{"A": 10, "C": 3})},
+			purged:    true,
+			hard:      true,
+			want:      RollbackStats{Deleted: 1, Kept: 1},
+			wantRows:  map[string]float64{"A": 10, "B": 2},
+			wantTotal: 2,
+		},
+		{
+			name:      "execução sem dados",
+			want:      RollbackStats{},
+			wantRows:  map[string]float64{"A": 1, "B": 2},
+			wantTotal: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepo(t)
+			upsert("run-1", map[string]float64{"A": 1, "B": 2})(t, r)
+			run := domain.Execution{RunID: "run-2", StartedAt: time.Now()}
+			for _, load := range tt.loads {
+				load(t, r)
+			}
+			if tt.purged {
+				if err := r.DB.Where("run_id = ?", run.RunID).Delete(&domain.RowSnapshot{}).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := r.RollbackRun(r.DB.WithContext(context.Background()), run, tt.hard)
+			if err != nil {
+				t.Fatalf("RollbackRun: %v", err)
+			}
+			tt.want.Table = "relatorio_pesos"
+			if got != tt.want {
+				t.Errorf("stats = %+v, esperado %+v", got, tt.want)
+			}
+
+			var rows []domain.RelatorioPeso
+			if err := r.DB.Find(&rows).Error; err != nil {
+				t.Fatal(err)
+			}
+			gotRows := map[string]float64{}
+			for _, row := range rows {
+				gotRows[row.Destino] = row.PesoCalculoTotal
+			}
+			if len(gotRows) != len(tt.wantRows) {
+				t.Errorf("linhas = %v, esperado %v", gotRows, tt.wantRows)
+			}
+			for destino, peso := range tt.wantRows {
+				if gotRows[destino] != peso {
+					t.Errorf("linhas = %v, esperado %v", gotRows, tt.wantRows)
+					break
+				}
+			}
+
+			var total int64
+			if err := r.DB.Unscoped().Model(&domain.RelatorioPeso{}).Count(&total).Error; err != nil {
+				t.Fatal(err)
+			}
+			if int(total) != tt.wantTotal {
+				t.Errorf("%d linhas na tabela, esperado %d", total, tt.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// tenantField é o campo que separa os dados de cada tenant (filial) na mesma tabela (coluna tenant_id)
+const tenantField = "TenantID"
+
+// ErrCrossTenant é devolvido quando uma linha de um tenant seria gravada com o ctx de outro
+var ErrCrossTenant = errors.New("escrita fora do tenant da execução")
+
+type tenantKey struct{}
+
+type allTenantsKey struct{}
+
+// WithTenant anexa o tenant ao ctx. Tudo que passar pelo GORM com esse ctx, em model com
+// campo TenantID, lê e grava só as linhas desse tenant (o robot.Service faz isso no Execute).
+// Sem tenant no ctx vale o tenant padrão "" (instalação de um tenant só).
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFrom lê o tenant do ctx ("" = tenant padrão)
+func TenantFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// AllTenants libera o ctx para enxergar todos os tenants. Só para rotinas de manutenção
+// (limpeza por retenção): repositório de execução nunca deve usar.
+func AllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allTenantsKey{}, true)
+}
+
+func allTenants(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	all, _ := ctx.Value(allTenantsKey{}).(bool)
+	return all
+}
+
+// RegisterTenancy instala os callbacks que isolam os tenants em todo model com campo TenantID:
+// INSERT sai com o tenant do ctx e SELECT/UPDATE/DELETE ganham "tenant_id = ?".
+// SQL escrito à mão (Raw/Exec) não passa por aqui: filtre o tenant_id nele você mesmo.
+func RegisterTenancy(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("tenant:stamp", stampTenant); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("tenant:scope", scopeTenant); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("tenant:scope", scopeTenantWrite); err != nil {
+		return err
+	}
+	return cb.Delete().Before("gorm:delete").Register("tenant:scope", scopeTenantWrite)
+}
+
+func tenantFieldOf(db *gorm.DB) *schema.Field {
+	if db.Statement.Schema == nil {
+		return nil
+	}
+	return db.Statement.Schema.LookUpField(tenantField)
+}
+
+func stampTenant(db *gorm.DB) {
+	field := tenantFieldOf(db)
+	if field == nil || allTenants(db.Statement.Context) {
+		return
+	}
+	if err := setTenant(db.Statement.Context, field, reflect.Indirect(db.Statement.ReflectValue)); err != nil {
+		db.AddError(err)
+	}
+}
+
+// setTenant carimba o tenant do ctx nas linhas sem tenant e recusa as que já são de outro
+func setTenant(ctx context.Context, field *schema.Field, rv reflect.Value) error {
+	tenant := TenantFrom(ctx)
+	check := func(row reflect.Value) error {
+		v, zero := field.ValueOf(ctx, row)
+		if zero {
+			if tenant == "" {
+				return nil
+			}
+			return field.Set(ctx, row, tenant)
+		}
+		if v != tenant {
+			return fmt.Errorf("%w: linha do tenant %q com o ctx do tenant %q", ErrCrossTenant, v, tenant)
+		}
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if elem := reflect.Indirect(rv.Index(i)); elem.Kind() == reflect.Struct {
+				if err := check(elem); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Struct:
+		return check(rv)
+	}
+	return nil
+}
+
+func scopeTenant(db *gorm.DB) {
+	field := tenantFieldOf(db)
+	if field == nil || allTenants(db.Statement.Context) {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: TenantFrom(db.Statement.Context)},
+	}})
+}
+
+// scopeTenantWrite é o scopeTenant do UPDATE/DELETE. O filtro de tenant não pode contar como
+// condição: um Delete(new(T)) sem WHERE continua sendo recusado pelo GORM (ErrMissingWhereClause)
+// em vez de apagar a tabela inteira do tenant.
+func scopeTenantWrite(db *gorm.DB) {
+	if tenantFieldOf(db) == nil || allTenants(db.Statement.Context) {
+		return
+	}
+	if !hasConditions(db) {
+		return
+	}
+	scopeTenant(db)
+}
+
+// hasConditions diz se o UPDATE/DELETE já tem condição própria: WHERE ou a chave primária do model
+func hasConditions(db *gorm.DB) bool {
+	if _, ok := db.Statement.Clauses["WHERE"]; ok || db.Statement.AllowGlobalUpdate {
+		return true
+	}
+	pk := db.Statement.Schema.PrioritizedPrimaryField
+	if pk == nil {
+		return false
+	}
+	ctx := db.Statement.Context
+	switch rv := reflect.Indirect(db.Statement.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if elem := reflect.Indirect(rv.Index(i)); elem.Kind() == reflect.Struct {
+				if _, zero := pk.ValueOf(ctx, elem); !zero {
+					return true
+				}
+			}
+		}
+	case reflect.Struct:
+		_, zero := pk.ValueOf(ctx, rv)
+		return !zero
+	}
+	return false
+}
+
+// stampTenant carimba o tenant do ctx no lote antes de gravar (o Upsert precisa dele na chave de conflito)
+func (r *Repository[T]) stampTenant(ctx context.Context, batch []T) error {
+	sch, err := r.schema()
+	if err != nil {
+		return err
+	}
+	field := sch.LookUpField(tenantField)
+	if field == nil || allTenants(ctx) {
+		return nil
+	}
+	return setTenant(ctx, field, reflect.ValueOf(batch))
+}
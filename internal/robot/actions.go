@@ -1,6 +1,7 @@
 package robot
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -11,6 +12,8 @@ import (
 	"os"
 
 	"github.com/rs/zerolog/log"
+
+	"github.com/botlorien/go-rpa-template/pkg/metrics"
 )
 
 var DefaultSSWHeaders = map[string]string{
@@ -29,23 +32,24 @@ var DefaultSSWHeaders = map[string]string{
 }
 
 // Login
-func (s *Session) Login(creds map[string]string) error {
+func (s *Session) Login(ctx context.Context, creds map[string]string) error {
 	if user, ok := creds["username"]; ok {
 		pass := creds["password"]
 		if s.UseRod {
-			return s.loginRod(user, pass)
+			return s.loginRod(ctx, user, pass)
 		}
-		return s.loginHTTP(user, pass)
+		return s.loginHTTP(ctx, user, pass)
 	}
 	return errors.New("nenhuma estratégia de autenticação válida encontrada")
 
 }
 
 // Implementação privada via ROD (Browser)
-func (s *Session) loginRod(user, pass string) error {
+func (s *Session) loginRod(ctx context.Context, user, pass string) error {
 	log.Debug().Msg("Realizando login via Browser")
 	
-	page := s.Browser.MustPage("https://targetUrl.com.br/login")
+	// Browser.Context faz as chamadas CDP respeitarem o cancelamento (shutdown)
+	page := s.Browser.Context(ctx).MustPage("https://targetUrl.com.br/login")
 	page.MustWaitLoad()
 
 	// Exemplo hipotético de seletores
@@ -62,7 +66,7 @@ func (s *Session) loginRod(user, pass string) error {
 }
 
 // Implementação privada via HTTP (Request)
-func (s *Session) loginHTTP(user, pass string) error {
+func (s *Session) loginHTTP(ctx context.Context, user, pass string) error {
 	log.Info().Msg("Iniciando Login via HTTP (SSW)")
 
 	targetURL := "https://targetUrl.com.br/login"
@@ -77,7 +81,7 @@ func (s *Session) loginHTTP(user, pass string) error {
 	// 2. Criação da Requisição
 	// formData.Encode() transforma o mapa em string: "act=L&f1=..."
 	log.Debug().Str("form_data", formData.Encode()).Msg("Payload do login HTTP")
-	req, err := http.NewRequest("POST", targetURL, strings.NewReader(formData.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", targetURL, strings.NewReader(formData.Encode()))
 	if err != nil {
 		return err
 	}
@@ -114,7 +118,7 @@ func (s *Session) loginHTTP(user, pass string) error {
 }
 
 // Outra ação: Baixar Relatório
-func (s *Session) BaixarRelatorio(pathDownload string) (string, error) {
+func (s *Session) BaixarRelatorio(ctx context.Context, pathDownload string) (string, error) {
     // Lógica para navegar até o relatório e baixar
 
 	log.Info().Msgf("Iniciando extração do relatório")
@@ -125,7 +129,7 @@ func (s *Session) BaixarRelatorio(pathDownload string) (string, error) {
 	step1Data.Set("sequencia", "19")
 	step1Data.Set("dummy", fmt.Sprintf("%d", time.Now().UnixMilli()))
 
-	req1, err := http.NewRequest("POST", endpoint, strings.NewReader(step1Data.Encode()))
+	req1, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(step1Data.Encode()))
 	if err != nil {
 		return "", err
 	}
@@ -167,7 +171,7 @@ func (s *Session) BaixarRelatorio(pathDownload string) (string, error) {
 	payloadDinamico.Set("start_date", hoje.Format(layoutData))
 	payloadDinamico.Set("relatorio_excel", "s") 
 	payloadDinamico.Set("dummy", fmt.Sprintf("%d", time.Now().UnixMilli()))
-	req2, err := http.NewRequest("POST", endpoint, strings.NewReader(payloadDinamico.Encode()))
+	req2, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(payloadDinamico.Encode()))
 	if err != nil {
 		return "", err
 	}
@@ -197,6 +201,8 @@ func (s *Session) BaixarRelatorio(pathDownload string) (string, error) {
         return "", nil
     }
 
+	metrics.DownloadBytes.Add(float64(len(finalBytes)))
+
 	// Simula o download do arquivo
 	pathFile := pathDownload + string(os.PathSeparator) + "arquivo.csv"
     os.WriteFile(pathFile, finalBytes, 0644)
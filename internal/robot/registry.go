@@ -0,0 +1,101 @@
+package robot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/botlorien/go-rpa-template/pkg/botapp"
+)
+
+// Metadata identifica o robô na API, na CLI e na dashboard do BotApp
+type Metadata struct {
+	Name        string `json:"name"` // Slug usado nas rotas e na CLI (ex: "relatorio-peso")
+	Description string `json:"description"`
+	Version     string `json:"version"` // Vazio = versão do build (tag + commit), preenchida no bootstrap
+	Department  string `json:"department"`
+	Commit      string `json:"commit,omitempty"`
+}
+
+// Robot é o contrato que cada robô implementa para rodar no mesmo binário
+type Robot interface {
+	Metadata() Metadata
+	Schema() InputSchema
+	Execute(ctx context.Context, input ExecutionInput) (any, error)
+}
+
+// Registry guarda os robôs disponíveis neste binário, indexados pelo nome
+type Registry struct {
+	mu     sync.RWMutex
+	robots map[string]Robot
+	order  []string // Ordem de registro: o primeiro é o padrão
+
+	Tenants *Tenants        // Tenants aceitos e config de cada um (nil = tenant opcional, sem config)
+	BotApp  botapp.Reporter // Client do BotApp já registrado (o do robô padrão): o readiness pinga por ele
+}
+
+func NewRegistry() *Registry {
+	return &Registry{robots: make(map[string]Robot)}
+}
+
+// Register adiciona um robô. Nomes duplicados são erro de programação.
+func (r *Registry) Register(rb Robot) error {
+	name := rb.Metadata().Name
+	if name == "" {
+		return fmt.Errorf("robô sem nome no Metadata")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.robots[name]; exists {
+		return fmt.Errorf("robô '%s' registrado duas vezes", name)
+	}
+	r.robots[name] = rb
+	r.order = append(r.order, name)
+	return nil
+}
+
+// Get busca um robô pelo nome
+func (r *Registry) Get(name string) (Robot, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rb, ok := r.robots[name]
+	return rb, ok
+}
+
+// Default retorna o primeiro robô registrado (usado pela rota legada /run)
+func (r *Registry) Default() (Robot, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.order) == 0 {
+		return nil, false
+	}
+	return r.robots[r.order[0]], true
+}
+
+// List retorna os robôs ordenados pelo nome
+func (r *Registry) List() []Robot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, len(r.order))
+	copy(names, r.order)
+	sort.Strings(names)
+
+	list := make([]Robot, len(names))
+	for i, name := range names {
+		list[i] = r.robots[name]
+	}
+	return list
+}
+
+// Names retorna os nomes registrados (para mensagens de erro e ajuda da CLI)
+func (r *Registry) Names() []string {
+	list := r.List()
+	names := make([]string, len(list))
+	for i, rb := range list {
+		names[i] = rb.Metadata().Name
+	}
+	return names
+}
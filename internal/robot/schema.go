@@ -0,0 +1,312 @@
+package robot
+
+import (
+	"math"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldType é o tipo JSON esperado para um campo do input
+type FieldType string
+
+const (
+	TypeString  FieldType = "string"
+	TypeNumber  FieldType = "number"
+	TypeInteger FieldType = "integer"
+	TypeBoolean FieldType = "boolean"
+)
+
+// Formatos suportados para campos string (mesmos nomes usados no OpenAPI)
+const (
+	FormatDate     = "date"      // 2006-01-02
+	FormatDateTime = "date-time" // RFC3339
+	FormatCNPJ     = "cnpj"      // Com ou sem máscara, valida dígitos verificadores
+	FormatCPF      = "cpf"       // Com ou sem máscara, valida dígitos verificadores
+	FormatEmail    = "email"
+)
+
+// Field descreve um campo de Auth ou Params
+type Field struct {
+	Name        string
+	Type        FieldType
+	Format      string
+	Required    bool
+	Description string
+	Enum        []string
+	Example     any
+}
+
+// InputSchema é o contrato declarado de cada robô para o ExecutionInput.
+// Ele é usado tanto para validar as requisições quanto para gerar o OpenAPI.
+type InputSchema struct {
+	Auth   []Field
+	Params []Field
+}
+
+// FieldError aponta exatamente qual campo do input está errado
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError agrupa todos os erros de campo encontrados no input
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return "input inválido: " + strings.Join(msgs, "; ")
+}
+
+// Validate confere o input contra o schema e retorna *ValidationError com todos os problemas.
+// Campos desconhecidos são rejeitados para pegar erros de digitação cedo.
+func (s InputSchema) Validate(input ExecutionInput) error {
+	var errs []FieldError
+
+	auth := make(map[string]any, len(input.Auth))
+	for k, v := range input.Auth {
+		auth[k] = v
+	}
+	errs = append(errs, validateGroup("auth", s.Auth, auth)...)
+	errs = append(errs, validateGroup("params", s.Params, input.Params)...)
+
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+func validateGroup(prefix string, fields []Field, values map[string]any) []FieldError {
+	var errs []FieldError
+	known := make(map[string]bool, len(fields))
+
+	for _, f := range fields {
+		known[f.Name] = true
+		path := prefix + "." + f.Name
+
+		val, ok := values[f.Name]
+		if !ok || val == nil || val == "" {
+			// String vazia conta como ausente: a CLI manda "" para variável de ambiente não definida
+			if f.Required {
+				errs = append(errs, FieldError{Field: path, Message: "campo obrigatório"})
+			}
+			continue
+		}
+
+		if msg := f.check(val); msg != "" {
+			errs = append(errs, FieldError{Field: path, Message: msg})
+		}
+	}
+
+	// Ordena os desconhecidos para a resposta ser estável entre chamadas
+	var unknown []string
+	for name := range values {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, FieldError{Field: prefix + "." + name, Message: "campo desconhecido"})
+	}
+
+	return errs
+}
+
+// check valida tipo, enum e formato de um valor presente. Retorna "" se estiver ok.
+// Strings numéricas/booleanas são aceitas porque a CLI lê tudo de variáveis de ambiente.
+func (f Field) check(val any) string {
+	switch f.Type {
+	case TypeNumber:
+		if _, ok := toFloat(val); !ok {
+			return "deve ser um número"
+		}
+	case TypeInteger:
+		n, ok := toFloat(val)
+		if !ok || n != math.Trunc(n) {
+			return "deve ser um inteiro"
+		}
+	case TypeBoolean:
+		switch v := val.(type) {
+		case bool:
+		case string:
+			if _, err := strconv.ParseBool(v); err != nil {
+				return "deve ser true ou false"
+			}
+		default:
+			return "deve ser true ou false"
+		}
+	default:
+		str, ok := val.(string)
+		if !ok {
+			return "deve ser texto"
+		}
+		if len(f.Enum) > 0 && !contains(f.Enum, str) {
+			return "deve ser um de: " + strings.Join(f.Enum, ", ")
+		}
+		return checkFormat(f.Format, str)
+	}
+	return ""
+}
+
+func checkFormat(format, val string) string {
+	switch format {
+	case FormatDate:
+		if _, err := time.Parse("2006-01-02", val); err != nil {
+			return "data inválida, use AAAA-MM-DD"
+		}
+	case FormatDateTime:
+		if _, err := time.Parse(time.RFC3339, val); err != nil {
+			return "data/hora inválida, use RFC3339 (AAAA-MM-DDTHH:MM:SSZ)"
+		}
+	case FormatCNPJ:
+		if !ValidCNPJ(val) {
+			return "CNPJ inválido"
+		}
+	case FormatCPF:
+		if !ValidCPF(val) {
+			return "CPF inválido"
+		}
+	case FormatEmail:
+		if _, err := mail.ParseAddress(val); err != nil {
+			return "e-mail inválido"
+		}
+	}
+	return ""
+}
+
+var nonDigits = regexp.MustCompile(`\D`)
+
+// ValidCNPJ valida um CNPJ (com ou sem máscara) pelos dígitos verificadores
+func ValidCNPJ(cnpj string) bool {
+	digits := nonDigits.ReplaceAllString(cnpj, "")
+	if len(digits) != 14 || allSame(digits) {
+		return false
+	}
+	weights1 := []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}
+	weights2 := []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}
+	return checkDigit(digits[:12], weights1) == int(digits[12]-'0') &&
+		checkDigit(digits[:13], weights2) == int(digits[13]-'0')
+}
+
+// ValidCPF valida um CPF (com ou sem máscara) pelos dígitos verificadores
+func ValidCPF(cpf string) bool {
+	digits := nonDigits.ReplaceAllString(cpf, "")
+	if len(digits) != 11 || allSame(digits) {
+		return false
+	}
+	weights1 := []int{10, 9, 8, 7, 6, 5, 4, 3, 2}
+	weights2 := []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2}
+	return checkDigit(digits[:9], weights1) == int(digits[9]-'0') &&
+		checkDigit(digits[:10], weights2) == int(digits[10]-'0')
+}
+
+// checkDigit calcula o dígito verificador módulo 11 (regra da Receita)
+func checkDigit(digits string, weights []int) int {
+	sum := 0
+	for i, w := range weights {
+		sum += int(digits[i]-'0') * w
+	}
+	rest := sum % 11
+	if rest < 2 {
+		return 0
+	}
+	return 11 - rest
+}
+
+func allSame(s string) bool {
+	return strings.Count(s, s[:1]) == len(s)
+}
+
+func toFloat(val any) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func contains(list []string, val string) bool {
+	for _, item := range list {
+		if item == val {
+			return true
+		}
+	}
+	return false
+}
+
+// JSONSchema converte o InputSchema no schema JSON do ExecutionInput (usado no OpenAPI)
+func (s InputSchema) JSONSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"auth":   groupJSONSchema(s.Auth),
+			"params": groupJSONSchema(s.Params),
+		},
+	}
+}
+
+func groupJSONSchema(fields []Field) map[string]any {
+	props := make(map[string]any, len(fields))
+	required := []string{}
+	for _, f := range fields {
+		props[f.Name] = f.JSONSchema()
+		if f.Required {
+			required = append(required, f.Name)
+		}
+	}
+	group := map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		group["required"] = required
+	}
+	return group
+}
+
+// JSONSchema converte o Field na definição JSON Schema/OpenAPI equivalente
+func (f Field) JSONSchema() map[string]any {
+	fieldType := f.Type
+	if fieldType == "" {
+		fieldType = TypeString
+	}
+	schema := map[string]any{"type": string(fieldType)}
+	if f.Format != "" {
+		schema["format"] = f.Format
+	}
+	switch f.Format {
+	case FormatCNPJ:
+		schema["pattern"] = `^\d{2}\.?\d{3}\.?\d{3}/?\d{4}-?\d{2}$`
+	case FormatCPF:
+		schema["pattern"] = `^\d{3}\.?\d{3}\.?\d{3}-?\d{2}$`
+	}
+	if f.Description != "" {
+		schema["description"] = f.Description
+	}
+	if len(f.Enum) > 0 {
+		schema["enum"] = f.Enum
+	}
+	if f.Example != nil {
+		schema["example"] = f.Example
+	}
+	return schema
+}
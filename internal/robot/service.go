@@ -1,30 +1,126 @@
 package robot
 
 import (
+	"context"
 	"errors"
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
+	"github.com/botlorien/go-rpa-template/internal/domain"
 	"github.com/botlorien/go-rpa-template/internal/repository"
 	"github.com/botlorien/go-rpa-template/pkg/utils"
 	"github.com/botlorien/go-rpa-template/pkg/botapp"
+	"github.com/botlorien/go-rpa-template/pkg/metrics"
 )
 
+// RelatorioMetadata identifica este robô de exemplo. Cada robô novo declara o seu.
+// A versão vem do build e descrição/departamento podem ser sobrescritos na config (BOT_*).
+var RelatorioMetadata = Metadata{
+	Name:        "relatorio-peso",
+	Description: "Extrai o relatório de peso por destino do sistema alvo",
+	Department:  "TI",
+}
+
 type Service struct {
-	Session *Session
-	Repo    *repository.RelatorioRepository
-	App     *botapp.Client
+	Session    *Session
+	Repo       *repository.RelatorioRepository
+	Executions *repository.ExecutionRepository
+	App        botapp.Reporter
+	Meta       Metadata // Metadados já resolvidos (config + build); vazio = RelatorioMetadata
 }
 
-func NewService(s *Session, r *repository.RelatorioRepository, a *botapp.Client) *Service {
+func NewService(s *Session, r *repository.RelatorioRepository, e *repository.ExecutionRepository, a botapp.Reporter) *Service {
 	return &Service{
-		Session: s,
-		Repo:    r,
-		App:	 a,
+		Session:    s,
+		Repo:       r,
+		Executions: e,
+		App:        a,
+	}
+}
+
+// Metadata identifica o robô na API, na CLI e na dashboard (implementa Robot)
+func (s *Service) Metadata() Metadata {
+	if s.Meta.Name == "" {
+		return RelatorioMetadata
+	}
+	return s.Meta
+}
+
+// Schema declara O QUE é obrigatório para ESSE robô específico.
+// É usado para validar as requisições (422) e gerar o /api/v1/openapi.json.
+func (s *Service) Schema() InputSchema {
+	return InputSchema{
+		Auth: []Field{
+			{Name: "username", Type: TypeString, Required: true, Description: "Usuário do sistema alvo"},
+			{Name: "password", Type: TypeString, Required: true, Description: "Senha do sistema alvo"},
+			{Name: "token", Type: TypeString, Description: "Token de acesso, se o sistema alvo exigir"},
+		},
+		Params: []Field{
+			{Name: "data_inicio", Type: TypeString, Format: FormatDate, Description: "Data inicial do relatório", Example: "2023-10-01"},
+			{Name: "filtro_id", Type: TypeString, Description: "ID do filtro aplicado no relatório", Example: "999"},
+			{Name: "baixar_pdf", Type: TypeBoolean, Description: "Também baixa a versão PDF do relatório"},
+		},
+	}
+}
+
+// Execute agora aceita o input genérico.
+// O ctx é cancelado no shutdown: as ações devem repassá-lo para pararem no meio.
+func (s *Service) Execute(ctx context.Context, input ExecutionInput) (resultado any, err error) {
+	start := time.Now()
+	defer func() { metrics.ObserveRun(time.Since(start), err) }()
+
+	// Registra a execução no banco. Falha aqui não impede o robô de rodar.
+	runID := uuid.NewString()
+	meta := s.Metadata()
+	trigger := botapp.TriggerFrom(ctx)
+	exec := &domain.Execution{
+		RunID:           runID,
+		TenantID:        input.Tenant,
+		Robot:           meta.Name,
+		RobotVersion:    meta.Version,
+		TriggerSource:   trigger.Source,
+		TriggerIdentity: trigger.Identity,
+		Input:           input.Redacted(),
+	}
+	var outputs []string
+	if startErr := s.Executions.Start(exec); startErr != nil {
+		log.Error().Err(startErr).Msg("Falha ao registrar execução no banco")
+	} else {
+		defer func() {
+			if err == nil {
+				exec.ResultSummary = botapp.ResultSummary(resultado, 0)
+			}
+			exec.Artifacts = outputs
+			if finishErr := s.Executions.Finish(exec, executionStatus(err), err); finishErr != nil {
+				log.Error().Err(finishErr).Msg("Falha ao finalizar execução no banco")
+			}
+		}()
+	}
+
+	// Tudo que os repositórios gravarem daqui em diante sai com o run_id desta execução,
+	// e só enxerga/grava as linhas do tenant dela
+	ctx = repository.WithRunID(ctx, runID)
+	ctx = repository.WithTenant(ctx, input.Tenant)
+
+	// Envelopa a pipeline inteira numa task da dashboard; as etapas viram Steps filhos dela
+	task, err := s.App.StartTask(ctx, "Execução Geral", "Executa pipeline completa")
+	if err != nil {
+		return nil, err
 	}
+	resultado, err = task.Run(func(task *botapp.TaskContext) (any, error) {
+		return s.pipeline(task, runID, input)
+	})
+	outputs = task.Outputs()
+	return resultado, err
 }
 
-// Execute agora aceita o input genérico
-func (s *Service) Execute(input ExecutionInput) (any, error) {
-	    log.Info().Str("dir", s.Session.DownloadDir).Msg("Limpando diretório de trabalho...")
+// pipeline é a lógica de negócio do robô (o conteúdo da def minha_tarefa() do Python).
+// Cada etapa roda num task.Step para a dashboard mostrar onde a execução está.
+func (s *Service) pipeline(task *botapp.TaskContext, runID string, input ExecutionInput) (resultado any, err error) {
+	ctx := task.Context()
+
+	    log.Info().Str("run_id", runID).Str("dir", s.Session.DownloadDir).Msg("Limpando diretório de trabalho...")
     
     if err := utils.EmptyDirectory(s.Session.DownloadDir); err != nil {
         // Se não conseguir limpar, é perigoso continuar
@@ -34,34 +130,37 @@ func (s *Service) Execute(input ExecutionInput) (any, error) {
 	log.Info().Msg("Iniciando execução com parâmetros dinâmicos")
 
 	// 1. Validação (Defensive Programming)
-	// O Service decide O QUE é obrigatório para ESSE robô específico
-	user := input.GetCredential("username")
-	pass := input.GetCredential("password")
-
-	if user == "" || pass == "" {
-		return nil, errors.New("credenciais 'username' e 'password' são obrigatórias")
+	// A API já valida antes de enfileirar, mas a CLI e outros chamadores passam direto por aqui
+	if err := s.Schema().Validate(input); err != nil {
+		return nil, err
 	}
 
-	// various tasks can be added here
-	var resultado any
-	var err error
+	// Ponto seguro para parar (bot desativado / shutdown) ou esperar (bot pausado na dashboard)
+	if err := task.Checkpoint(); err != nil {
+		return nil, err
+	}
 
-	loginTask := func() (any, error){
-		if err := s.Session.Login(input.Auth); err != nil {
+	// various steps can be added here (use step.Progress e step.Checkpoint em loops longos,
+	// e step.AddOutput(caminho, botapp.ArtifactReport) para anexar os arquivos gerados ao log).
+	// Relatório baixado -> banco: processor.LoadFile + processor.Decode[domain.RelatorioPeso] +
+	// s.Repo.SaveBatch(ctx, dataReferencia, linhas) (a data do relatório, ex: input.Params["data_inicio"])
+	// (cargas grandes sem chave natural: s.Repo.BulkInsert, que usa COPY no postgres)
+	return task.Step("LoginTask", func(step *botapp.TaskContext) (any, error) {
+		if err := s.Session.Login(ctx, input.Auth); err != nil {
 			return nil, err
 		}
 		return nil, nil
-	}
-
-	if s.App != nil {
-		resultado, err = s.App.RunTask(
-			"LoginTask",
-			"Description Task", 
-			loginTask,
-	)
+	})
+}
 
-	} else {
-		resultado, err = loginTask()
+// executionStatus traduz o erro da execução para o status gravado no banco
+func executionStatus(err error) string {
+	switch {
+	case err == nil:
+		return domain.ExecutionCompleted
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return domain.ExecutionInterrupted
+	default:
+		return domain.ExecutionFailed
 	}
-	return resultado, err
-}
\ No newline at end of file
+}
@@ -1,6 +1,8 @@
 package robot
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/cookiejar"
 	"path/filepath"
@@ -10,6 +12,8 @@ import (
 	"github.com/go-rod/rod/lib/launcher"
 	"github.com/go-rod/rod/lib/proto"
 	"github.com/rs/zerolog/log"
+
+	"github.com/botlorien/go-rpa-template/pkg/metrics"
 )
 
 // Session segura as conexões.
@@ -31,8 +35,9 @@ func NewSession(useRod bool, headless bool, downloadDir string) *Session {
 	// Configura HTTP Client com Cookies (Jar)
 	jar, _ := cookiejar.New(nil)
 	client := &http.Client{
-		Jar:     jar,
-		Timeout: 30 * time.Second,
+		Jar:       jar,
+		Timeout:   30 * time.Second,
+		Transport: metrics.InstrumentTransport(http.DefaultTransport), // Conta requisições por status code
 	}
 
 	sess := &Session{
@@ -66,6 +71,30 @@ func NewSession(useRod bool, headless bool, downloadDir string) *Session {
 	return sess
 }
 
+// Ping verifica se o browser ainda responde. Sem Rod (modo HTTP) não há o que checar.
+func (s *Session) Ping(ctx context.Context) error {
+	if !s.UseRod {
+		return nil
+	}
+	if s.Browser == nil {
+		return errors.New("browser não inicializado")
+	}
+	_, err := proto.BrowserGetVersion{}.Call(s.Browser.Context(ctx))
+	return err
+}
+
+// PagesOpen retorna quantas abas o browser tem abertas (0 no modo HTTP)
+func (s *Session) PagesOpen() int {
+	if s.Browser == nil {
+		return 0
+	}
+	pages, err := s.Browser.Pages()
+	if err != nil {
+		return 0
+	}
+	return len(pages)
+}
+
 func (s *Session) Close() {
 	if s.Browser != nil {
 		s.Browser.MustClose()
@@ -0,0 +1,101 @@
+package robot
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// tenantPattern é o formato aceito para o ID do tenant (vira coluna no banco e prefixo de config)
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Erros do Authorize (a API traduz para 401/403)
+var (
+	ErrTenantKeyMissing = errors.New("chave de API obrigatória para este tenant")
+	ErrTenantForbidden  = errors.New("chave de API não libera este tenant")
+)
+
+// TenantSettings é o que a config guarda por tenant: chave da API, credenciais e params padrão
+type TenantSettings struct {
+	APIKey string // Vazio = tenant sem chave (só aceito sem lista de tenants)
+	Auth   map[string]string
+	Params map[string]any
+}
+
+// Tenants decide quais tenants podem rodar e completa o input com a config de cada um.
+// API e CLI chamam Resolve antes de validar o input contra o schema do robô.
+type Tenants struct {
+	Allowed  []string                           // Vazio = tenant opcional, qualquer ID válido
+	Settings func(tenant string) TenantSettings // Config do tenant (nil = sem config por tenant)
+}
+
+// Resolve normaliza e valida o tenant do input e preenche o que faltar em Auth/Params
+// com a config do tenant. O que veio no input tem prioridade.
+func (t *Tenants) Resolve(input *ExecutionInput) error {
+	input.Tenant = strings.ToLower(strings.TrimSpace(input.Tenant))
+
+	var allowed []string
+	if t != nil {
+		allowed = t.Allowed
+	}
+	switch {
+	case input.Tenant == "" && len(allowed) > 0:
+		return &ValidationError{Errors: []FieldError{{Field: "tenant", Message: "obrigatório (use " + strings.Join(allowed, ", ") + ")"}}}
+	case input.Tenant == "":
+		return nil
+	case !tenantPattern.MatchString(input.Tenant):
+		return &ValidationError{Errors: []FieldError{{Field: "tenant", Message: "use letras minúsculas, números, _ ou - (até 64)"}}}
+	case len(allowed) > 0 && !slices.Contains(allowed, input.Tenant):
+		return &ValidationError{Errors: []FieldError{{Field: "tenant", Message: fmt.Sprintf("tenant não configurado: %s", input.Tenant)}}}
+	}
+
+	if t == nil || t.Settings == nil {
+		return nil
+	}
+	settings := t.Settings(input.Tenant)
+	if len(settings.Auth) > 0 && input.Auth == nil {
+		input.Auth = map[string]string{}
+	}
+	for k, v := range settings.Auth {
+		if input.Auth[k] == "" {
+			input.Auth[k] = v
+		}
+	}
+	if len(settings.Params) > 0 && input.Params == nil {
+		input.Params = map[string]any{}
+	}
+	for k, v := range settings.Params {
+		if cur, ok := input.Params[k]; !ok || cur == "" || cur == nil {
+			input.Params[k] = v
+		}
+	}
+	return nil
+}
+
+// Authorize confere se a chave de quem chama a API libera o tenant (já normalizado pelo Resolve).
+// Tenant com chave configurada exige a chave dele. Com lista de tenants, tenant sem chave é
+// recusado: senão qualquer chamador leria/apagaria os dados de outra filial (e rodaria com as
+// credenciais dela) só trocando o X-Tenant-ID. A CLI não passa por aqui: quem roda tem a config.
+func (t *Tenants) Authorize(tenant, key string) error {
+	if t == nil || tenant == "" {
+		return nil
+	}
+	var want string
+	if t.Settings != nil {
+		want = t.Settings(tenant).APIKey
+	}
+	switch {
+	case want == "" && len(t.Allowed) == 0:
+		return nil
+	case want == "":
+		return fmt.Errorf("%w: %s sem chave configurada", ErrTenantForbidden, tenant)
+	case key == "":
+		return ErrTenantKeyMissing
+	case subtle.ConstantTimeCompare([]byte(key), []byte(want)) != 1:
+		return fmt.Errorf("%w: %s", ErrTenantForbidden, tenant)
+	}
+	return nil
+}
@@ -1,5 +1,7 @@
 package robot
 
+import "strings"
+
 // ExecutionInput é o contrato de entrada do seu robô.
 // Ele serve tanto para o JSON da API quanto para argumentos de CLI.
 type ExecutionInput struct {
@@ -8,6 +10,10 @@ type ExecutionInput struct {
 
 	// Params: Dados variáveis da execução (filtros, datas, IDs)
 	Params map[string]any `json:"params"`
+
+	// Tenant: filial/empresa dona dos dados desta execução ("" = tenant padrão).
+	// Credenciais e params da config do tenant completam Auth/Params (ver Tenants.Resolve).
+	Tenant string `json:"tenant,omitempty"`
 }
 
 // Helper para validar se uma credencial existe
@@ -23,4 +29,36 @@ func (i *ExecutionInput) GetParams(key string) any {
 		return val
 	}
 	return ""
-}
\ No newline at end of file
+}
+
+// redactedValue substitui credenciais no que vai para o histórico de execuções
+const redactedValue = "***"
+
+// sensitiveParams são pedaços de nome de parâmetro que indicam segredo (ex: "api_key", "senha_certificado")
+var sensitiveParams = []string{"password", "senha", "token", "secret", "key"}
+
+// Redacted devolve o input pronto para ser gravado (histórico no banco):
+// todo o Auth e os Params com cara de segredo saem mascarados.
+func (i *ExecutionInput) Redacted() map[string]any {
+	auth := make(map[string]any, len(i.Auth))
+	for k, v := range i.Auth {
+		if v == "" {
+			auth[k] = ""
+			continue
+		}
+		auth[k] = redactedValue
+	}
+
+	params := make(map[string]any, len(i.Params))
+	for k, v := range i.Params {
+		params[k] = v
+		lower := strings.ToLower(k)
+		for _, s := range sensitiveParams {
+			if strings.Contains(lower, s) {
+				params[k] = redactedValue
+				break
+			}
+		}
+	}
+	return map[string]any{"tenant": i.Tenant, "auth": auth, "params": params}
+}
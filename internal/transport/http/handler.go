@@ -1,22 +1,49 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strings"
 
+	"github.com/botlorien/go-rpa-template/internal/queue"
+	"github.com/botlorien/go-rpa-template/internal/repository"
 	"github.com/botlorien/go-rpa-template/internal/robot"
+	"github.com/botlorien/go-rpa-template/pkg/botapp"
+	"github.com/botlorien/go-rpa-template/pkg/health"
+	"github.com/botlorien/go-rpa-template/pkg/metrics"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 )
 
+// Headers que identificam a origem da execução (vão para o log da dashboard)
+const (
+	headerTriggerSource = "X-Trigger-Source" // api (padrão), scheduler, webhook
+	headerRequestedBy   = "X-Requested-By"   // Usuário ou job que pediu
+	headerTenant        = "X-Tenant-ID"      // Filial dona dos dados (alternativa ao "tenant" do JSON)
+	headerAPIKey        = "X-API-Key"        // Chave do tenant (TENANT_<ID>_API_KEY): prova que o chamador é da filial
+)
+
 // Handler segura as dependências necessárias para lidar com as requisições
 type Handler struct {
-	Service *robot.Service
+	Robots     *robot.Registry
+	Queue      *queue.Queue
+	Health     *health.Checker
+	Rollback   *repository.Rollback
+	Relatorios *repository.RelatorioRepository
+	ReportsDir string // Onde ficam os arquivos temporários das exportações CSV/XLSX
 }
 
 // NewHandler é o construtor
-func NewHandler(s *robot.Service) *Handler {
+func NewHandler(robots *robot.Registry, q *queue.Queue, hc *health.Checker, rb *repository.Rollback,
+	relatorios *repository.RelatorioRepository, reportsDir string) *Handler {
 	return &Handler{
-		Service: s,
+		Robots:     robots,
+		Queue:      q,
+		Health:     hc,
+		Rollback:   rb,
+		Relatorios: relatorios,
+		ReportsDir: reportsDir,
 	}
 }
 
@@ -24,18 +51,74 @@ func NewHandler(s *robot.Service) *Handler {
 func (h *Handler) RegisterRoutes(r *gin.Engine) {
 	api := r.Group("/api/v1") // Boa prática: versionamento
 	{
-		api.POST("/run", h.RunRPA)
+		api.POST("/run", h.RunRPA) // Legado: executa o robô padrão
+		api.GET("/robots", h.ListRobots)
+		api.POST("/robots/:name/run", h.RunRobot)
+		api.DELETE("/executions/:id/data", h.RollbackExecution)
+		api.GET("/relatorios", h.ListRelatorios)
 		api.GET("/health", h.HealthCheck)
+		api.GET("/openapi.json", h.OpenAPI)
 	}
+
+	// Probes do orquestrador (Kubernetes) ficam fora do versionamento
+	r.GET("/health/live", h.HealthCheck)
+	r.GET("/health/ready", h.Readiness)
+
+	// Métricas no formato texto do Prometheus
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
 }
 
-// HealthCheck é um endpoint simples
+// HealthCheck (liveness) só confirma que o processo está de pé e atendendo HTTP.
+// Não checa dependências: se o banco cair, reiniciar o pod não resolve.
 func (h *Handler) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"status": "up"})
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
+}
+
+// Readiness checa as dependências e responde 503 se alguma crítica estiver fora,
+// para o orquestrador parar de rotear tráfego para esta instância.
+func (h *Handler) Readiness(c *gin.Context) {
+	report := h.Health.Run(c.Request.Context())
+
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
 }
 
-// RunRPA é a função que o Gin vai chamar
+// ListRobots lista os robôs disponíveis neste binário
+func (h *Handler) ListRobots(c *gin.Context) {
+	robots := h.Robots.List()
+	list := make([]robot.Metadata, len(robots))
+	for i, rb := range robots {
+		list[i] = rb.Metadata()
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// RunRPA executa o robô padrão (primeiro registrado). Mantido para quem já chama /run.
 func (h *Handler) RunRPA(c *gin.Context) {
+	rb, ok := h.Robots.Default()
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "nenhum robô registrado"})
+		return
+	}
+	h.run(c, rb)
+}
+
+// RunRobot executa o robô escolhido pelo nome na rota
+func (h *Handler) RunRobot(c *gin.Context) {
+	name := c.Param("name")
+	rb, ok := h.Robots.Get(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "robô não encontrado: " + name, "robots": h.Robots.Names()})
+		return
+	}
+	h.run(c, rb)
+}
+
+// run é o fluxo comum de execução via HTTP
+func (h *Handler) run(c *gin.Context, rb robot.Robot) {
 	var input robot.ExecutionInput
 
 	// O Gin lê o JSON e preenche os Maps automaticamente
@@ -43,15 +126,70 @@ func (h *Handler) RunRPA(c *gin.Context) {
 		c.JSON(400, gin.H{"error": "JSON inválido", "details": err.Error()})
 		return
 	}
+	// Tenant pelo JSON ou pelo header; os dois juntos precisam bater
+	if header := c.GetHeader(headerTenant); header != "" {
+		if input.Tenant != "" && !strings.EqualFold(input.Tenant, header) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "tenant do JSON diferente do header " + headerTenant})
+			return
+		}
+		input.Tenant = header
+	}
+	// Antes do schema: as credenciais podem vir da config do tenant
+	if err := h.Robots.Tenants.Resolve(&input); err != nil {
+		h.invalidInput(c, err)
+		return
+	}
+	if err := h.Robots.Tenants.Authorize(input.Tenant, c.GetHeader(headerAPIKey)); err != nil {
+		h.invalidInput(c, err)
+		return
+	}
+
+	// Valida contra o schema declarado pelo robô antes de ocupar a fila
+	if err := rb.Schema().Validate(input); err != nil {
+		h.invalidInput(c, err)
+		return
+	}
+
+	// Quem disparou: agendadores e webhooks que chamam a API se identificam pelos headers
+	trigger := botapp.Trigger{Source: botapp.TriggerAPI, Identity: c.ClientIP()}
+	if source := c.GetHeader(headerTriggerSource); source != "" {
+		if !botapp.ValidTrigger(source) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "origem de execução inválida: " + source})
+			return
+		}
+		trigger.Source = source
+	}
+	if who := c.GetHeader(headerRequestedBy); who != "" {
+		trigger.Identity = who
+	}
+
 	// 1. Log da entrada (Contexto HTTP)
 	log.Info().
 		Str("client_ip", c.ClientIP()).
+		Str("robot", rb.Metadata().Name).
+		Str("trigger", trigger.Source).
+		Str("requested_by", trigger.Identity).
+		Str("tenant", input.Tenant).
 		Msg("Recebida solicitação de execução via HTTP")
 
 	// 2. Chama o Service (O Robô)
 	// Note que o handler não sabe COMO o robô funciona, só pede para executar.
-	data, err := h.Service.Execute(input)
+	// A fila limita quantas execuções rodam ao mesmo tempo (o browser é compartilhado)
+	data, err := h.Queue.Do(botapp.WithTrigger(c.Request.Context(), trigger), func(ctx context.Context) (any, error) {
+		return rb.Execute(ctx, input)
+	})
 
+	if errors.Is(err, queue.ErrClosed) {
+		// Shutdown em andamento: o orquestrador deve mandar para outra instância
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, queue.ErrFull) {
+		log.Warn().Interface("fila", h.Queue.Stats()).Msg("Execução rejeitada: fila cheia")
+		c.Header("Retry-After", "30")
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("Erro na execução do serviço")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -61,3 +199,79 @@ func (h *Handler) RunRPA(c *gin.Context) {
 	// 3. Retorna a resposta (Tradução para HTTP)
 	c.JSON(http.StatusOK, data)
 }
+
+// RollbackExecution desfaz os dados carregados por uma execução (:id é o run_id).
+// ?hard=true apaga de verdade as linhas inseridas; ?force=true desfaz uma execução
+// que ficou "running" porque o processo morreu.
+func (h *Handler) RollbackExecution(c *gin.Context) {
+	req := repository.RollbackRequest{
+		RunID:         c.Param("id"),
+		Hard:          c.Query("hard") == "true",
+		Force:         c.Query("force") == "true",
+		TriggerSource: botapp.TriggerAPI,
+		RequestedBy:   c.ClientIP(),
+	}
+	if who := c.GetHeader(headerRequestedBy); who != "" {
+		req.RequestedBy = who
+	}
+
+	ctx, err := h.tenantContext(c)
+	if err != nil {
+		h.invalidInput(c, err)
+		return
+	}
+
+	log.Warn().
+		Str("tenant", repository.TenantFrom(ctx)).
+		Str("run_id", req.RunID).
+		Str("requested_by", req.RequestedBy).
+		Bool("hard", req.Hard).
+		Msg("Recebido pedido de rollback via HTTP")
+
+	report, err := h.Rollback.Run(ctx, req)
+	switch {
+	case errors.Is(err, repository.ErrRunNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrRunStillRunning), errors.Is(err, repository.ErrRunRolledBack):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		log.Error().Err(err).Str("run_id", req.RunID).Msg("Erro no rollback")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// tenantContext valida o header X-Tenant-ID (e a X-API-Key do tenant) e devolve o ctx
+// da requisição restrito a esse tenant.
+// Rotas que leem ou apagam dados passam por aqui: sem o header vale o tenant padrão.
+func (h *Handler) tenantContext(c *gin.Context) (context.Context, error) {
+	input := robot.ExecutionInput{Tenant: c.GetHeader(headerTenant)}
+	if err := h.Robots.Tenants.Resolve(&input); err != nil {
+		return nil, err
+	}
+	if err := h.Robots.Tenants.Authorize(input.Tenant, c.GetHeader(headerAPIKey)); err != nil {
+		return nil, err
+	}
+	return repository.WithTenant(c.Request.Context(), input.Tenant), nil
+}
+
+// invalidInput responde 422 com os campos do ValidationError (schema do robô ou tenant),
+// ou 401/403 quando a chave de API não libera o tenant
+func (h *Handler) invalidInput(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, robot.ErrTenantKeyMissing):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, robot.ErrTenantForbidden):
+		log.Warn().Err(err).Str("client_ip", c.ClientIP()).Msg("Acesso a tenant recusado")
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	var verr *robot.ValidationError
+	if errors.As(err, &verr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "input inválido", "fields": verr.Errors})
+		return
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+}
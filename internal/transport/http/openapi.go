@@ -0,0 +1,290 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/botlorien/go-rpa-template/internal/robot"
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPI serve a especificação OpenAPI 3 gerada a partir do InputSchema de cada robô
+func (h *Handler) OpenAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, h.openAPIDocument())
+}
+
+func (h *Handler) openAPIDocument() map[string]any {
+	errorSchema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"error":   map[string]any{"type": "string"},
+			"details": map[string]any{"type": "string"},
+		},
+	}
+	validationSchema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"error": map[string]any{"type": "string"},
+			"fields": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"field":   map[string]any{"type": "string", "example": "params.data_inicio"},
+						"message": map[string]any{"type": "string", "example": "data inválida, use AAAA-MM-DD"},
+					},
+				},
+			},
+		},
+	}
+
+	metadataSchema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":        map[string]any{"type": "string"},
+			"description": map[string]any{"type": "string"},
+			"version":     map[string]any{"type": "string"},
+			"department":  map[string]any{"type": "string"},
+		},
+	}
+
+	rollbackSchema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"run_id":          map[string]any{"type": "string"},
+			"rollback_run_id": map[string]any{"type": "string"},
+			"tables": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"table":     map[string]any{"type": "string"},
+						"deleted":   map[string]any{"type": "integer"},
+						"restored":  map[string]any{"type": "integer"},
+						"conflicts": map[string]any{"type": "integer"},
+						"kept":      map[string]any{"type": "integer", "description": "Linhas sobrescritas pela execução sem snapshot: ficam como estão"},
+					},
+				},
+			},
+		},
+	}
+
+	relatorioSchema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"id":                 map[string]any{"type": "integer"},
+			"destino":            map[string]any{"type": "string"},
+			"peso_calculo_total": map[string]any{"type": "number"},
+			"data_processamento": map[string]any{"type": "string", "format": "date"},
+			"run_id":             map[string]any{"type": "string"},
+			"updated_at":         map[string]any{"type": "string", "format": "date-time"},
+		},
+	}
+	relatorioPageSchema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"items":       map[string]any{"type": "array", "items": ref("Relatorio")},
+			"next_cursor": map[string]any{"type": "string", "description": "Ausente na última página"},
+		},
+	}
+
+	schemas := map[string]any{
+		"Error":           errorSchema,
+		"ValidationError": validationSchema,
+		"RobotMetadata":   metadataSchema,
+		"RollbackReport":  rollbackSchema,
+		"Relatorio":       relatorioSchema,
+		"RelatorioPage":   relatorioPageSchema,
+	}
+	paths := map[string]any{
+		"/api/v1/robots": map[string]any{
+			"get": map[string]any{
+				"summary": "Lista os robôs disponíveis",
+				"responses": map[string]any{
+					"200": map[string]any{
+						"description": "Robôs registrados",
+						"content":     jsonContent(map[string]any{"type": "array", "items": ref("RobotMetadata")}),
+					},
+				},
+			},
+		},
+		"/api/v1/health": simpleGet("Liveness (alias de /health/live)"),
+		"/health/live":   simpleGet("Liveness: o processo está de pé"),
+		"/health/ready": map[string]any{
+			"get": map[string]any{
+				"summary": "Readiness: checa banco, browser, disco, fila e BotApp",
+				"responses": map[string]any{
+					"200": map[string]any{"description": "Pronto para receber tráfego"},
+					"503": map[string]any{"description": "Algum componente crítico está fora"},
+				},
+			},
+		},
+		"/api/v1/executions/{id}/data": map[string]any{
+			"delete": map[string]any{
+				"summary":     "Desfaz os dados carregados por uma execução",
+				"description": "Apaga as linhas que a execução inseriu e devolve as que ela sobrescreveu. O rollback fica registrado no histórico de execuções.",
+				"parameters": []any{
+					map[string]any{"name": "id", "in": "path", "required": true, "description": "run_id da execução", "schema": map[string]any{"type": "string"}},
+					map[string]any{"name": "hard", "in": "query", "description": "Apaga de verdade em vez de soft delete", "schema": map[string]any{"type": "boolean"}},
+					map[string]any{"name": "force", "in": "query", "description": "Desfaz mesmo com a execução ainda como running", "schema": map[string]any{"type": "boolean"}},
+					map[string]any{"name": headerRequestedBy, "in": "header", "description": "Quem pediu o rollback. Padrão: IP do cliente", "schema": map[string]any{"type": "string"}},
+					tenantParam(),
+					apiKeyParam(),
+				},
+				"responses": tenantAuthResponses(map[string]any{
+					"200": map[string]any{"description": "Rollback concluído", "content": jsonContent(ref("RollbackReport"))},
+					"404": map[string]any{"description": "Execução não encontrada (ou de outro tenant)", "content": jsonContent(ref("Error"))},
+					"409": map[string]any{"description": "Execução rodando ou já desfeita", "content": jsonContent(ref("Error"))},
+					"500": map[string]any{"description": "Erro no rollback (nada foi alterado)", "content": jsonContent(ref("Error"))},
+				}),
+			},
+		},
+		"/api/v1/relatorios": map[string]any{
+			"get": map[string]any{
+				"summary":     "Lista os dados gravados pelo robô",
+				"description": "Paginação por cursor: passe o next_cursor da resposta para ler a próxima página. Com Accept text/csv ou XLSX (ou ?format=) devolve arquivo, com o próximo cursor no header X-Next-Cursor.",
+				"parameters": []any{
+					map[string]any{"name": "destino", "in": "query", "schema": map[string]any{"type": "string"}},
+					map[string]any{"name": "from", "in": "query", "description": "Data de processamento inicial (inclusiva)", "schema": map[string]any{"type": "string", "format": "date"}},
+					map[string]any{"name": "to", "in": "query", "description": "Data de processamento final (inclusiva)", "schema": map[string]any{"type": "string", "format": "date"}},
+					map[string]any{"name": "sort", "in": "query", "description": "Coluna de ordenação; \"-\" na frente = decrescente", "schema": map[string]any{
+						"type": "string", "default": "-data_processamento",
+						"enum": []string{"data_processamento", "-data_processamento", "destino", "-destino", "peso_calculo_total", "-peso_calculo_total", "id", "-id"},
+					}},
+					map[string]any{"name": "limit", "in": "query", "description": "Linhas por página (JSON: até 1000; arquivo: até 50000)", "schema": map[string]any{"type": "integer"}},
+					map[string]any{"name": "cursor", "in": "query", "schema": map[string]any{"type": "string"}},
+					map[string]any{"name": "format", "in": "query", "description": "Alternativa ao Accept", "schema": map[string]any{"type": "string", "enum": []string{"json", "csv", "xlsx"}}},
+					tenantParam(),
+					apiKeyParam(),
+				},
+				"responses": tenantAuthResponses(map[string]any{
+					"200": map[string]any{"description": "Página do relatório", "content": map[string]any{
+						"application/json": map[string]any{"schema": ref("RelatorioPage")},
+						mimeCSV:            map[string]any{"schema": map[string]any{"type": "string"}},
+						mimeXLSX:           map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}},
+					}},
+					"400": map[string]any{"description": "Filtro, ordenação ou cursor inválido", "content": jsonContent(ref("Error"))},
+					"422": map[string]any{"description": "Tenant inválido ou não liberado", "content": jsonContent(ref("ValidationError"))},
+					"500": map[string]any{"description": "Erro ao consultar o banco", "content": jsonContent(ref("Error"))},
+				}),
+			},
+		},
+		"/metrics":             simpleGet("Métricas no formato texto do Prometheus"),
+		"/api/v1/openapi.json": simpleGet("Esta especificação"),
+	}
+
+	// Uma rota (e um schema de input) por robô registrado
+	for _, rb := range h.Robots.List() {
+		meta := rb.Metadata()
+		schemaName := toSchemaName(meta.Name) + "Input"
+		schemas[schemaName] = rb.Schema().JSONSchema()
+		paths["/api/v1/robots/"+meta.Name+"/run"] = runOperation(meta, schemaName)
+	}
+	if rb, ok := h.Robots.Default(); ok {
+		op := runOperation(rb.Metadata(), toSchemaName(rb.Metadata().Name)+"Input")
+		op["post"].(map[string]any)["deprecated"] = true
+		paths["/api/v1/run"] = op
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "RPA API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+		},
+	}
+}
+
+func runOperation(meta robot.Metadata, schemaName string) map[string]any {
+	return map[string]any{
+		"post": map[string]any{
+			"summary":     "Executa o robô " + meta.Name,
+			"description": meta.Description,
+			"parameters": []any{
+				map[string]any{
+					"name": headerTriggerSource, "in": "header",
+					"description": "Origem da execução na dashboard (padrão: api)",
+					"schema":      map[string]any{"type": "string", "enum": []string{"api", "scheduler", "webhook"}},
+				},
+				map[string]any{
+					"name": headerRequestedBy, "in": "header",
+					"description": "Quem pediu a execução (usuário, job do agendador...). Padrão: IP do cliente",
+					"schema":      map[string]any{"type": "string"},
+				},
+				tenantParam(),
+				apiKeyParam(),
+			},
+			"requestBody": map[string]any{
+				"required": true,
+				"content":  jsonContent(ref(schemaName)),
+			},
+			"responses": tenantAuthResponses(map[string]any{
+				"200": map[string]any{"description": "Execução concluída", "content": jsonContent(map[string]any{})},
+				"400": map[string]any{"description": "JSON inválido ou tenant do header diferente do body", "content": jsonContent(ref("Error"))},
+				"404": map[string]any{"description": "Robô não encontrado", "content": jsonContent(ref("Error"))},
+				"422": map[string]any{"description": "Input não confere com o schema do robô ou tenant inválido", "content": jsonContent(ref("ValidationError"))},
+				"429": map[string]any{"description": "Fila de execuções cheia", "content": jsonContent(ref("Error"))},
+				"500": map[string]any{"description": "Erro na execução", "content": jsonContent(ref("Error"))},
+				"503": map[string]any{"description": "Servidor em shutdown", "content": jsonContent(ref("Error"))},
+			}),
+		},
+	}
+}
+
+// tenantParam é o header de tenant, igual em todas as rotas que leem ou gravam dados
+func tenantParam() map[string]any {
+	return map[string]any{
+		"name": headerTenant, "in": "header",
+		"description": "Tenant (filial) dono dos dados. Sem header: tenant padrão",
+		"schema":      map[string]any{"type": "string", "pattern": "^[a-z0-9][a-z0-9_-]{0,63}$"},
+	}
+}
+
+// apiKeyParam é a chave do tenant, exigida junto com o tenantParam
+func apiKeyParam() map[string]any {
+	return map[string]any{
+		"name": headerAPIKey, "in": "header",
+		"description": "Chave do tenant (TENANT_<ID>_API_KEY). Obrigatória com TENANTS configurado ou se o tenant tiver chave",
+		"schema":      map[string]any{"type": "string"},
+	}
+}
+
+// tenantAuthResponses são as respostas da checagem da chave do tenant
+func tenantAuthResponses(responses map[string]any) map[string]any {
+	responses["401"] = map[string]any{"description": "Falta a chave de API do tenant", "content": jsonContent(ref("Error"))}
+	responses["403"] = map[string]any{"description": "A chave de API não libera este tenant", "content": jsonContent(ref("Error"))}
+	return responses
+}
+
+// toSchemaName transforma "relatorio-peso" em "RelatorioPeso"
+func toSchemaName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, p := range parts {
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return strings.Join(parts, "")
+}
+
+func ref(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+func simpleGet(summary string) map[string]any {
+	return map[string]any{
+		"get": map[string]any{
+			"summary":   summary,
+			"responses": map[string]any{"200": map[string]any{"description": "OK"}},
+		},
+	}
+}
@@ -0,0 +1,200 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/botlorien/go-rpa-template/internal/domain"
+	"github.com/botlorien/go-rpa-template/internal/processor"
+	"github.com/botlorien/go-rpa-template/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	mimeCSV  = "text/csv"
+	mimeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+	relatoriosDefaultLimit = 100   // Página padrão no JSON
+	relatoriosMaxLimit     = 1000  // Maior página no JSON
+	relatoriosExportLimit  = 50000 // Linhas por arquivo no CSV/XLSX (padrão e máximo)
+)
+
+// relatorioView é a linha do relatório como sai na API (JSON e colunas do CSV/XLSX)
+type relatorioView struct {
+	ID                uint      `json:"id"`
+	Destino           string    `json:"destino"`
+	PesoCalculoTotal  float64   `json:"peso_calculo_total"`
+	DataProcessamento string    `json:"data_processamento"` // AAAA-MM-DD
+	RunID             string    `json:"run_id,omitempty"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// relatorioHeaders são as colunas do CSV/XLSX. Destino, Peso e Data batem com as tags df do
+// domain.RelatorioPeso (o peso sai com vírgula decimal, como o br_float espera),
+// então o arquivo exportado pode voltar pelo processor.Decode.
+var relatorioHeaders = []string{"ID", "Destino", "Peso Cálculo Total", "Data Processamento", "Run ID", "Atualizado Em"}
+
+// ListRelatorios lista os dados gravados pelo robô, sem precisar de acesso ao banco.
+//
+//	?destino=SAO PAULO          filtro exato
+//	?from=2025-01-01&to=...     data de processamento, AAAA-MM-DD, as duas inclusivas
+//	?sort=-data_processamento   coluna de ordenação; "-" na frente = decrescente (padrão)
+//	?limit=100&cursor=...       paginação: o cursor vem no next_cursor da página anterior
+//
+// Só devolve as linhas do tenant do header X-Tenant-ID (sem header: tenant padrão),
+// se a X-API-Key for a do tenant.
+// JSON por padrão; Accept: text/csv ou o mime do XLSX (ou ?format=csv|xlsx) devolve arquivo,
+// com o cursor da próxima página no header X-Next-Cursor.
+func (h *Handler) ListRelatorios(c *gin.Context) {
+	format := c.Query("format")
+	if format == "" {
+		switch c.NegotiateFormat(gin.MIMEJSON, mimeCSV, mimeXLSX) {
+		case mimeCSV:
+			format = "csv"
+		case mimeXLSX:
+			format = "xlsx"
+		default:
+			format = "json"
+		}
+	}
+	if format != "json" && format != "csv" && format != "xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "formato inválido: " + format + " (use json, csv ou xlsx)"})
+		return
+	}
+
+	filter, err := relatorioFilterFrom(c, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, err := h.tenantContext(c)
+	if err != nil {
+		h.invalidInput(c, err)
+		return
+	}
+
+	page, err := h.Relatorios.List(ctx, filter)
+	switch {
+	case errors.Is(err, repository.ErrInvalidCursor), errors.Is(err, repository.ErrInvalidSort):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Error().Err(err).Msg("Erro ao listar relatórios")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]relatorioView, len(page.Items))
+	for i, r := range page.Items {
+		items[i] = toRelatorioView(r)
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, repository.Page[relatorioView]{Items: items, NextCursor: page.NextCursor})
+		return
+	}
+
+	if page.NextCursor != "" {
+		c.Header("X-Next-Cursor", page.NextCursor)
+	}
+	h.sendFrame(c, relatorioFrame(items), "relatorios."+format)
+}
+
+// relatorioFilterFrom lê os filtros da query string
+func relatorioFilterFrom(c *gin.Context, format string) (repository.RelatorioFilter, error) {
+	filter := repository.RelatorioFilter{
+		Destino: c.Query("destino"),
+		Cursor:  c.Query("cursor"),
+		Sort:    "data_processamento",
+		Desc:    true,
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		filter.Desc = strings.HasPrefix(sort, "-")
+		filter.Sort = strings.TrimPrefix(sort, "-")
+	}
+
+	var err error
+	if from := c.Query("from"); from != "" {
+		if filter.From, err = time.ParseInLocation("2006-01-02", from, time.Local); err != nil {
+			return filter, fmt.Errorf("from inválido, use AAAA-MM-DD: %s", from)
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		day, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("to inválido, use AAAA-MM-DD: %s", to)
+		}
+		filter.To = day.AddDate(0, 0, 1) // Inclusivo: o dia inteiro entra
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, fmt.Errorf("período inválido: from depois de to")
+	}
+
+	maxLimit, limit := relatoriosMaxLimit, relatoriosDefaultLimit
+	if format != "json" {
+		maxLimit, limit = relatoriosExportLimit, relatoriosExportLimit
+	}
+	if raw := c.Query("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit <= 0 || limit > maxLimit {
+			return filter, fmt.Errorf("limit inválido: %s (entre 1 e %d)", raw, maxLimit)
+		}
+	}
+	filter.Limit = limit
+	return filter, nil
+}
+
+func toRelatorioView(r domain.RelatorioPeso) relatorioView {
+	return relatorioView{
+		ID:                r.ID,
+		Destino:           r.Destino,
+		PesoCalculoTotal:  r.PesoCalculoTotal,
+		DataProcessamento: r.DataProcessamento.Format("2006-01-02"),
+		RunID:             r.RunID,
+		UpdatedAt:         r.UpdatedAt,
+	}
+}
+
+func relatorioFrame(items []relatorioView) *processor.DataFrame {
+	df := processor.NewDataFrame()
+	df.Headers = relatorioHeaders
+	for _, it := range items {
+		df.Rows = append(df.Rows, processor.Row{
+			"ID":                 strconv.FormatUint(uint64(it.ID), 10),
+			"Destino":            it.Destino,
+			"Peso Cálculo Total": processor.FormatFloatBR(it.PesoCalculoTotal, 2), // br_float no domain
+			"Data Processamento": it.DataProcessamento,
+			"Run ID":             it.RunID,
+			"Atualizado Em":      it.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	return df
+}
+
+// sendFrame exporta o DataFrame (DataFrame.Export) num arquivo temporário em PATH_REPORTS
+// e manda como anexo; a extensão de name decide entre CSV e XLSX
+func (h *Handler) sendFrame(c *gin.Context, df *processor.DataFrame, name string) {
+	tmp, err := os.CreateTemp(h.ReportsDir, "export-*-"+name)
+	if err != nil {
+		log.Error().Err(err).Msg("Erro ao criar arquivo de exportação")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	path := tmp.Name()
+	tmp.Close()
+	defer os.Remove(path)
+
+	if err := df.Export(path); err != nil {
+		log.Error().Err(err).Str("arquivo", name).Msg("Erro ao exportar relatório")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.FileAttachment(path, name)
+}
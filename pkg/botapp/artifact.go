@@ -0,0 +1,274 @@
+package botapp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultMaxArtifactBytes é o maior arquivo aceito por UploadArtifact (sobrescrito por Config.MaxArtifactBytes)
+const DefaultMaxArtifactBytes = 100 << 20
+
+// Tipos comuns de artefato (a dashboard usa para agrupar/ícone; qualquer string é aceita)
+const (
+	ArtifactReport     = "report"     // Relatório baixado do sistema alvo
+	ArtifactExport     = "export"     // Arquivo gerado pelo robô (ex: DataFrame.Export)
+	ArtifactScreenshot = "screenshot" // Print de tela (útil em falhas)
+	ArtifactLog        = "log"
+)
+
+// Artifact é um arquivo anexado a um log na dashboard
+type Artifact struct {
+	ID      int    `json:"id"`
+	TaskLog int    `json:"tasklog"`
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	SHA256  string `json:"sha256"`
+}
+
+// artifactMeta são os campos de formulário enviados junto com o arquivo.
+// TaskLog pode ser "{{ref:xxx}}" quando o log ainda está no outbox.
+type artifactMeta struct {
+	TaskLog any    `json:"tasklog"`
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	SHA256  string `json:"sha256"`
+}
+
+// output é um arquivo declarado pela task para ser anexado quando ela terminar
+type output struct {
+	path string
+	kind string
+}
+
+// UploadArtifact anexa um arquivo ao log (multipart em /artifacts/), com o sha256 para conferência.
+// Com a API fora, uma cópia do arquivo vai para o outbox e o upload é refeito depois;
+// nesse caso o retorno é nil, nil.
+func (c *Client) UploadArtifact(logID int, path, kind string) (*Artifact, error) {
+	return c.uploadArtifact(resourceRef{ID: logID}, path, kind)
+}
+
+func (c *Client) uploadArtifact(log resourceRef, path, kind string) (*Artifact, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("artefato %s é um diretório", path)
+	}
+	if limit := c.maxArtifactBytes(); info.Size() > limit {
+		return nil, fmt.Errorf("artefato %s tem %d bytes, acima do limite de %d", path, info.Size(), limit)
+	}
+
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := json.Marshal(artifactMeta{
+		TaskLog: log.Value(),
+		Kind:    kind,
+		Name:    filepath.Base(path),
+		Size:    info.Size(),
+		SHA256:  sum,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ev := outboxEvent{Kind: eventUpload, Method: "POST", Endpoint: "/artifacts/", Payload: meta}
+	if c.outbox == nil {
+		return decodeArtifact(c.doUpload(ev.Endpoint, ev.Payload, path))
+	}
+
+	if resolved, missing := c.outbox.resolveRefs(ev); missing == "" && c.outbox.Pending() == 0 {
+		resp, err := c.doUpload(resolved.Endpoint, resolved.Payload, path)
+		if err == nil || !isRetryable(err) {
+			return decodeArtifact(resp, err)
+		}
+		fmt.Printf("⚠️ BotApp inacessível, guardando upload de %s no outbox: %v\n", filepath.Base(path), err)
+	}
+
+	// O arquivo original pode ser apagado/sobrescrito (ex: próxima execução limpa a pasta)
+	ev.File, err = c.outbox.StoreFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.outbox.Enqueue(ev); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// doUpload envia o arquivo como multipart/form-data, com os campos de meta ao lado.
+// O corpo é montado em streaming (io.Pipe) direto do disco: um artefato de 100 MB não
+// passa pela memória, nem na primeira tentativa nem no reenvio depois de um 401.
+func (c *Client) doUpload(endpoint string, meta json.RawMessage, path string) ([]byte, error) {
+	fields := map[string]string{}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(meta, &raw); err != nil {
+		return nil, err
+	}
+	for name, v := range raw {
+		// Strings vão sem aspas; números (ID do log, tamanho) vão como estão
+		var value string
+		if err := json.Unmarshal(v, &value); err != nil {
+			value = string(v)
+		}
+		fields[name] = value
+	}
+
+	// No replay o arquivo é a cópia do outbox: o nome original vem do meta
+	filename := filepath.Base(path)
+	if fields["name"] != "" {
+		filename = fields["name"]
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	// Mesmo boundary em todo corpo gerado: o Content-Type vale para o reenvio também
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+
+	// Content-Length = formulário sem o arquivo + tamanho do arquivo (sem chunked, que nem todo proxy aceita)
+	var overhead countingWriter
+	if err := writeUploadForm(&overhead, boundary, fields, filename, strings.NewReader("")); err != nil {
+		return nil, err
+	}
+
+	body := func() (io.ReadCloser, error) {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		pr, pw := io.Pipe()
+		go func() {
+			defer file.Close()
+			pw.CloseWithError(writeUploadForm(pw, boundary, fields, filename, file))
+		}()
+		return pr, nil
+	}
+
+	first, err := body()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(context.Background(), "POST", c.Config.APIURL+endpoint, first)
+	if err != nil {
+		first.Close()
+		return nil, err
+	}
+	req.GetBody = body
+	req.ContentLength = int64(overhead) + info.Size()
+	req.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+	return c.do(req)
+}
+
+// writeUploadForm escreve o multipart do upload: os campos de meta e depois o arquivo
+func writeUploadForm(w io.Writer, boundary string, fields map[string]string, filename string, content io.Reader) error {
+	form := multipart.NewWriter(w)
+	if err := form.SetBoundary(boundary); err != nil {
+		return err
+	}
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			return err
+		}
+	}
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, content); err != nil {
+		return err
+	}
+	return form.Close()
+}
+
+// countingWriter só conta os bytes (tamanho do formulário sem o arquivo)
+type countingWriter int64
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+func decodeArtifact(resp []byte, err error) (*Artifact, error) {
+	if err != nil {
+		return nil, err
+	}
+	var artifact Artifact
+	if err := json.Unmarshal(resp, &artifact); err != nil {
+		return nil, err
+	}
+	return &artifact, nil
+}
+
+func (c *Client) maxArtifactBytes() int64 {
+	if c.Config.MaxArtifactBytes > 0 {
+		return c.Config.MaxArtifactBytes
+	}
+	return DefaultMaxArtifactBytes
+}
+
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// AddOutput declara um arquivo gerado pela task (ex: relatório baixado, XLSX exportado).
+// Ele é anexado ao log automaticamente quando a task termina, com sucesso ou não.
+func (t *TaskContext) AddOutput(path, kind string) {
+	t.mu.Lock()
+	t.outputs = append(t.outputs, output{path: path, kind: kind})
+	t.mu.Unlock()
+
+	// A task raiz junta os arquivos da árvore inteira (Outputs)
+	root := t
+	for root.parent != nil {
+		root = root.parent
+	}
+	root.mu.Lock()
+	root.declared = append(root.declared, path)
+	root.mu.Unlock()
+}
+
+// Outputs lista os arquivos declarados com AddOutput nesta task e em todos os Steps dela
+// (chamado na task raiz, depois do Run, para registrar no histórico da execução)
+func (t *TaskContext) Outputs() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.declared...)
+}
+
+// uploadOutputs anexa os arquivos declarados com AddOutput (chamado no finish)
+func (t *TaskContext) uploadOutputs() {
+	t.mu.Lock()
+	outputs := t.outputs
+	t.mu.Unlock()
+
+	for _, out := range outputs {
+		if _, err := t.client.uploadArtifact(t.log, out.path, out.kind); err != nil {
+			fmt.Printf("⚠️ Erro ao anexar %s: %v\n", out.path, err)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package botapp
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Modos de autenticação aceitos em Config.AuthMode
+const (
+	AuthBasic = "basic" // Usuário e senha em toda chamada (padrão, compatível com o comportamento antigo)
+	AuthToken = "token" // Token do DRF: "Authorization: Token <token>"
+	AuthJWT   = "jwt"   // Obtém access/refresh no endpoint de token e renova sozinho no 401
+	AuthNone  = "none"  // Sem header: a identidade vem do certificado cliente (mTLS)
+)
+
+// Authenticator coloca as credenciais na requisição antes de ela sair
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// Refresher é implementado pelos Authenticators que conseguem renovar a credencial.
+// Quando a API responde 401, o Client chama Refresh e repete a requisição uma vez.
+type Refresher interface {
+	Refresh(ctx context.Context) error
+}
+
+// BasicAuth é o usuário/senha da API
+type BasicAuth struct {
+	User     string
+	Password string
+}
+
+func (a *BasicAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(a.User, a.Password)
+	return nil
+}
+
+// TokenAuth usa o token do DRF. Com File, o token é lido do arquivo (ex: secret montado
+// no Kubernetes) e relido no 401: rotacionar o secret não exige redeploy.
+type TokenAuth struct {
+	Token string
+	File  string
+
+	mu sync.RWMutex
+}
+
+func (a *TokenAuth) Authenticate(req *http.Request) error {
+	a.mu.RLock()
+	token := a.Token
+	a.mu.RUnlock()
+	if token == "" {
+		return fmt.Errorf("token do BotApp vazio")
+	}
+	req.Header.Set("Authorization", "Token "+token)
+	return nil
+}
+
+func (a *TokenAuth) Refresh(ctx context.Context) error {
+	if a.File == "" {
+		return fmt.Errorf("token fixo não pode ser renovado")
+	}
+	data, err := os.ReadFile(a.File)
+	if err != nil {
+		return fmt.Errorf("falha ao ler token de %s: %w", a.File, err)
+	}
+	a.mu.Lock()
+	a.Token = strings.TrimSpace(string(data))
+	a.mu.Unlock()
+	return nil
+}
+
+// JWTAuth obtém o par access/refresh com usuário e senha (padrão do simplejwt)
+// e renova o access quando a API responde 401.
+type JWTAuth struct {
+	TokenURL   string // Ex: https://botapp/api/token/
+	RefreshURL string // Ex: https://botapp/api/token/refresh/
+	User       string
+	Password   string
+	HTTPClient *http.Client
+
+	mu      sync.Mutex
+	access  string
+	refresh string
+}
+
+func (a *JWTAuth) Authenticate(req *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.access == "" {
+		if err := a.obtainLocked(req.Context()); err != nil {
+			return err
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+a.access)
+	return nil
+}
+
+// Refresh renova o access com o refresh token; se ele também expirou, faz login de novo
+func (a *JWTAuth) Refresh(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.refresh != "" {
+		var tokens struct {
+			Access  string `json:"access"`
+			Refresh string `json:"refresh"` // Só vem com ROTATE_REFRESH_TOKENS
+		}
+		if err := a.post(ctx, a.RefreshURL, map[string]string{"refresh": a.refresh}, &tokens); err == nil && tokens.Access != "" {
+			a.access = tokens.Access
+			if tokens.Refresh != "" {
+				a.refresh = tokens.Refresh
+			}
+			return nil
+		}
+	}
+	return a.obtainLocked(ctx)
+}
+
+func (a *JWTAuth) obtainLocked(ctx context.Context) error {
+	var tokens struct {
+		Access  string `json:"access"`
+		Refresh string `json:"refresh"`
+	}
+	if err := a.post(ctx, a.TokenURL, map[string]string{"username": a.User, "password": a.Password}, &tokens); err != nil {
+		return fmt.Errorf("falha ao obter token JWT: %w", err)
+	}
+	if tokens.Access == "" {
+		return fmt.Errorf("endpoint de token JWT não retornou access")
+	}
+	a.access, a.refresh = tokens.Access, tokens.Refresh
+	return nil
+}
+
+func (a *JWTAuth) post(ctx context.Context, url string, payload, out any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return json.Unmarshal(body, out)
+}
+
+type noAuth struct{}
+
+func (noAuth) Authenticate(*http.Request) error { return nil }
+
+// newAuthenticator monta o Authenticator a partir da Config (Config.Auth tem prioridade)
+func newAuthenticator(cfg Config, httpClient *http.Client) (Authenticator, error) {
+	if cfg.Auth != nil {
+		return cfg.Auth, nil
+	}
+
+	switch strings.ToLower(cfg.AuthMode) {
+	case "", AuthBasic:
+		return &BasicAuth{User: cfg.User, Password: cfg.Password}, nil
+	case AuthToken:
+		auth := &TokenAuth{Token: cfg.Token, File: cfg.TokenFile}
+		if auth.File != "" {
+			if err := auth.Refresh(context.Background()); err != nil {
+				return nil, err
+			}
+		}
+		if auth.Token == "" {
+			return nil, fmt.Errorf("modo de autenticação token exige BOTAPP_API_TOKEN ou BOTAPP_API_TOKEN_FILE")
+		}
+		return auth, nil
+	case AuthJWT:
+		tokenURL, refreshURL := cfg.JWTTokenURL, cfg.JWTRefreshURL
+		if tokenURL == "" {
+			tokenURL = cfg.APIURL + "/token/"
+		}
+		if refreshURL == "" {
+			refreshURL = cfg.APIURL + "/token/refresh/"
+		}
+		return &JWTAuth{
+			TokenURL:   tokenURL,
+			RefreshURL: refreshURL,
+			User:       cfg.User,
+			Password:   cfg.Password,
+			HTTPClient: httpClient,
+		}, nil
+	case AuthNone, "mtls":
+		if cfg.TLSCertFile == "" {
+			return nil, fmt.Errorf("modo de autenticação %s sem certificado cliente (BOTAPP_TLS_CERT)", cfg.AuthMode)
+		}
+		return noAuth{}, nil
+	default:
+		return nil, fmt.Errorf("modo de autenticação do BotApp desconhecido: %s", cfg.AuthMode)
+	}
+}
+
+// newTLSConfig monta o TLS do mTLS. O certificado cliente é relido a cada handshake,
+// então renovar os arquivos (ex: cert-manager) não exige reiniciar o robô.
+func newTLSConfig(cfg Config) (*tls.Config, error) {
+	if cfg.TLSCertFile == "" && cfg.TLSCAFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.TLSCertFile != "" {
+		if cfg.TLSKeyFile == "" {
+			return nil, fmt.Errorf("BOTAPP_TLS_CERT informado sem BOTAPP_TLS_KEY")
+		}
+		// Valida já na subida para não descobrir o erro só na primeira chamada
+		if _, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile); err != nil {
+			return nil, fmt.Errorf("certificado cliente inválido: %w", err)
+		}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			return &cert, nil
+		}
+	}
+
+	if cfg.TLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("falha ao ler CA do BotApp: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("nenhum certificado válido em %s", cfg.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
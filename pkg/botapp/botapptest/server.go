@@ -0,0 +1,491 @@
+// Package botapptest é um BotApp falso, em memória, para testar robôs sem a dashboard real.
+//
+//	srv := botapptest.NewServer()
+//	defer srv.Close()
+//	app, _ := botapp.NewClient(srv.Config())
+//	... roda o robô com app ...
+//	logs := srv.Logs() // confere o que foi reportado
+package botapptest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/botlorien/go-rpa-template/pkg/botapp"
+)
+
+// Log é um tasklog como a API guardaria: os campos do POST com os PATCHes aplicados por cima
+type Log map[string]any
+
+// ID do log
+func (l Log) ID() int { return toInt(l["id"]) }
+
+// TaskID é a Task a que o log pertence
+func (l Log) TaskID() int { return toInt(l["task"]) }
+
+// ParentID é o log pai (Steps); 0 na task raiz
+func (l Log) ParentID() int { return toInt(l["parent_log"]) }
+
+// Status do log (started, completed, failed, interrupted, abandoned)
+func (l Log) Status() string { return l.String("status") }
+
+// String lê um campo texto do log
+func (l Log) String(key string) string {
+	s, _ := l[key].(string)
+	return s
+}
+
+// Artifact é um arquivo recebido em /artifacts/
+type Artifact struct {
+	botapp.Artifact
+	Content []byte
+}
+
+// Request é uma chamada recebida (útil para conferir ordem e payloads)
+type Request struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+// Server é o BotApp falso. Os métodos são seguros para uso concorrente.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	nextID    int
+	bots      map[int]*botapp.Bot
+	tasks     map[int]*botapp.Task
+	logs      map[int]Log
+	artifacts []Artifact
+	requests  []Request
+	failures  []int // Status a devolver nas próximas chamadas (simula API fora)
+}
+
+// NewServer sobe o servidor falso numa porta local
+func NewServer() *Server {
+	s := &Server{
+		bots:  map[int]*botapp.Bot{},
+		tasks: map[int]*botapp.Task{},
+		logs:  map[int]Log{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /bots/", s.listBots)
+	mux.HandleFunc("POST /bots/", s.createBot)
+	mux.HandleFunc("GET /bots/{id}/", s.getBot)
+	mux.HandleFunc("PATCH /bots/{id}/", s.patchBot)
+	mux.HandleFunc("GET /tasks/", s.listTasks)
+	mux.HandleFunc("POST /tasks/", s.createTask)
+	mux.HandleFunc("PATCH /tasks/{id}/", s.patchTask)
+	mux.HandleFunc("GET /tasklog/", s.listLogs)
+	mux.HandleFunc("POST /tasklog/", s.createLog)
+	mux.HandleFunc("GET /tasklog/{id}/", s.getLog)
+	mux.HandleFunc("PATCH /tasklog/{id}/", s.patchLog)
+	mux.HandleFunc("POST /artifacts/", s.createArtifact)
+
+	s.Server = httptest.NewServer(s.record(mux))
+	return s
+}
+
+// Config devolve a config de um Client apontado para este servidor
+func (s *Server) Config() botapp.Config {
+	return botapp.Config{APIURL: s.URL, User: "test", Password: "test"}
+}
+
+// FailNext faz as próximas len(statuses) chamadas responderem com esses status (ex: 503)
+func (s *Server) FailNext(statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, statuses...)
+}
+
+// SetActive liga/desliga o bot (kill-switch da dashboard)
+func (s *Server) SetActive(botID int, active bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if bot, ok := s.bots[botID]; ok {
+		bot.IsActive = active
+	}
+}
+
+// SetPaused pausa/retoma o bot
+func (s *Server) SetPaused(botID int, paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if bot, ok := s.bots[botID]; ok {
+		bot.IsPaused = paused
+	}
+}
+
+// Bots registrados, em ordem de criação
+func (s *Server) Bots() []botapp.Bot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]botapp.Bot, 0, len(s.bots))
+	for _, id := range sortedKeys(s.bots) {
+		out = append(out, *s.bots[id])
+	}
+	return out
+}
+
+// Tasks registradas, em ordem de criação
+func (s *Server) Tasks() []botapp.Task {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]botapp.Task, 0, len(s.tasks))
+	for _, id := range sortedKeys(s.tasks) {
+		out = append(out, *s.tasks[id])
+	}
+	return out
+}
+
+// Logs gravados, em ordem de criação (cópias: alterar não afeta o servidor)
+func (s *Server) Logs() []Log {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Log, 0, len(s.logs))
+	for _, id := range sortedKeys(s.logs) {
+		out = append(out, copyLog(s.logs[id]))
+	}
+	return out
+}
+
+// Log busca um log pelo ID
+func (s *Server) Log(id int) (Log, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.logs[id]
+	if !ok {
+		return nil, false
+	}
+	return copyLog(l), true
+}
+
+// Artifacts recebidos, em ordem de chegada
+func (s *Server) Artifacts() []Artifact {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Artifact(nil), s.artifacts...)
+}
+
+// Requests recebidas, em ordem de chegada (inclusive as que falharam por FailNext)
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// --- Handlers ---
+
+func (s *Server) record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := ""
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+			data, _ := io.ReadAll(r.Body)
+			body = string(data)
+			r.Body = io.NopCloser(strings.NewReader(body))
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.RequestURI(), Body: body})
+		status := 0
+		if len(s.failures) > 0 {
+			status, s.failures = s.failures[0], s.failures[1:]
+		}
+		s.mu.Unlock()
+
+		if status != 0 {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) listBots(w http.ResponseWriter, r *http.Request) {
+	search := r.URL.Query().Get("search")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []botapp.Bot{}
+	for _, id := range sortedKeys(s.bots) {
+		if bot := s.bots[id]; search == "" || strings.Contains(bot.Name, search) {
+			out = append(out, *bot)
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) createBot(w http.ResponseWriter, r *http.Request) {
+	var bot botapp.Bot
+	if !readJSON(w, r, &bot) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bot.ID = s.newID()
+	s.bots[bot.ID] = &bot
+	writeJSON(w, http.StatusCreated, bot)
+}
+
+func (s *Server) getBot(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bot, ok := s.bots[pathID(r)]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, http.StatusOK, bot)
+}
+
+func (s *Server) patchBot(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bot, ok := s.bots[pathID(r)]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	// Decodifica por cima: só os campos enviados mudam
+	if !readJSON(w, r, bot) {
+		return
+	}
+	writeJSON(w, http.StatusOK, bot)
+}
+
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) {
+	botID, _ := strconv.Atoi(r.URL.Query().Get("bot"))
+	name := r.URL.Query().Get("name")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []botapp.Task{}
+	for _, id := range sortedKeys(s.tasks) {
+		task := s.tasks[id]
+		if (botID == 0 || task.BotID == botID) && (name == "" || task.Name == name) {
+			out = append(out, *task)
+		}
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) createTask(w http.ResponseWriter, r *http.Request) {
+	var task botapp.Task
+	if !readJSON(w, r, &task) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.bots[task.BotID]; !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"bot": []string{"bot inexistente"}})
+		return
+	}
+	task.ID = s.newID()
+	s.tasks[task.ID] = &task
+	writeJSON(w, http.StatusCreated, task)
+}
+
+func (s *Server) patchTask(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tasks[pathID(r)]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if !readJSON(w, r, task) {
+		return
+	}
+	writeJSON(w, http.StatusOK, task)
+}
+
+func (s *Server) listLogs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	botID, _ := strconv.Atoi(q.Get("bot"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []Log{}
+	for _, id := range sortedKeys(s.logs) {
+		l := s.logs[id]
+		if botID != 0 {
+			if task, ok := s.tasks[l.TaskID()]; !ok || task.BotID != botID {
+				continue
+			}
+		}
+		if status := q.Get("status"); status != "" && l.Status() != status {
+			continue
+		}
+		if host := q.Get("host_name"); host != "" && l.String("host_name") != host {
+			continue
+		}
+		out = append(out, l)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) createLog(w http.ResponseWriter, r *http.Request) {
+	l := Log{}
+	if !readJSON(w, r, &l) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tasks[l.TaskID()]; !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"task": []string{"task inexistente"}})
+		return
+	}
+	if parent := l.ParentID(); parent != 0 {
+		if _, ok := s.logs[parent]; !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"parent_log": []string{"log pai inexistente"}})
+			return
+		}
+	}
+	l["id"] = s.newID()
+	s.logs[l.ID()] = l
+	writeJSON(w, http.StatusCreated, l)
+}
+
+func (s *Server) getLog(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.logs[pathID(r)]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, http.StatusOK, l)
+}
+
+func (s *Server) patchLog(w http.ResponseWriter, r *http.Request) {
+	patch := Log{}
+	if !readJSON(w, r, &patch) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.logs[pathID(r)]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	for k, v := range patch {
+		if k != "id" {
+			l[k] = v
+		}
+	}
+	writeJSON(w, http.StatusOK, l)
+}
+
+func (s *Server) createArtifact(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"detail": err.Error()})
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"file": []string{err.Error()}})
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"file": []string{err.Error()}})
+		return
+	}
+
+	logID, _ := strconv.Atoi(r.FormValue("tasklog"))
+	size, _ := strconv.ParseInt(r.FormValue("size"), 10, 64)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.logs[logID]; !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"tasklog": []string{"log inexistente"}})
+		return
+	}
+	artifact := Artifact{
+		Artifact: botapp.Artifact{
+			ID:      s.newID(),
+			TaskLog: logID,
+			Kind:    r.FormValue("kind"),
+			Name:    header.Filename,
+			Size:    size,
+			SHA256:  r.FormValue("sha256"),
+		},
+		Content: content,
+	}
+	s.artifacts = append(s.artifacts, artifact)
+	writeJSON(w, http.StatusCreated, artifact.Artifact)
+}
+
+// --- Helpers ---
+
+// newID gera IDs únicos entre todos os recursos (facilita achar bugs de ID trocado). Chamar com mu travado.
+func (s *Server) newID() int {
+	s.nextID++
+	return s.nextID
+}
+
+func pathID(r *http.Request) int {
+	id, _ := strconv.Atoi(r.PathValue("id"))
+	return id
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"detail": fmt.Sprintf("JSON inválido: %v", err)})
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func toInt(v any) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case float64:
+		return int(n)
+	case string:
+		// DRF aceita a PK como string ("12"), então o fake também
+		i, _ := strconv.Atoi(n)
+		return i
+	default:
+		return 0
+	}
+}
+
+func copyLog(l Log) Log {
+	out := make(Log, len(l))
+	for k, v := range l {
+		out[k] = v
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
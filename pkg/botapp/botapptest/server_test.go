@@ -0,0 +1,110 @@
+package botapptest_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/botlorien/go-rpa-template/pkg/botapp"
+	"github.com/botlorien/go-rpa-template/pkg/botapp/botapptest"
+)
+
+func newClient(t *testing.T, srv *botapptest.Server, outbox bool) *botapp.Client {
+	t.Helper()
+	cfg := srv.Config()
+	if outbox {
+		cfg.OutboxDir = t.TempDir()
+	}
+	app, err := botapp.NewClient(cfg)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if err := app.SetBot("robo teste", "bot de teste", "1.0.0", "ti"); err != nil {
+		t.Fatalf("SetBot: %v", err)
+	}
+	return app
+}
+
+func TestRunTaskReportsLog(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status string
+	}{
+		{name: "sucesso", status: "completed"},
+		{name: "falha", err: errors.New("site fora do ar"), status: "failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := botapptest.NewServer()
+			defer srv.Close()
+			app := newClient(t, srv, false)
+
+			_, err := app.RunTask("baixar_relatorio", "Baixa o relatório", func() (any, error) {
+				return map[string]any{"linhas": 3}, tt.err
+			})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("RunTask devolveu %v, esperado %v", err, tt.err)
+			}
+
+			if bots := srv.Bots(); len(bots) != 1 || bots[0].Name != "Robo Teste" {
+				t.Fatalf("bots = %+v, esperado um bot \"Robo Teste\"", bots)
+			}
+			tasks := srv.Tasks()
+			if len(tasks) != 1 || tasks[0].Name != "baixar_relatorio" {
+				t.Fatalf("tasks = %+v, esperado uma task baixar_relatorio", tasks)
+			}
+			logs := srv.Logs()
+			if len(logs) != 1 {
+				t.Fatalf("%d logs, esperado 1", len(logs))
+			}
+			if logs[0].Status() != tt.status || logs[0].TaskID() != tasks[0].ID {
+				t.Errorf("log status=%s task=%d, esperado %s/%d", logs[0].Status(), logs[0].TaskID(), tt.status, tasks[0].ID)
+			}
+		})
+	}
+}
+
+// Com a API fora durante a execução, o log vai para o outbox e chega inteiro no replay
+func TestOutboxReplaysAfterOutage(t *testing.T) {
+	srv := botapptest.NewServer()
+	defer srv.Close()
+	app := newClient(t, srv, true)
+
+	outage := make([]int, 50)
+	for i := range outage {
+		outage[i] = http.StatusServiceUnavailable
+	}
+	srv.FailNext(outage...)
+
+	if _, err := app.RunTask("baixar_relatorio", "Baixa o relatório", func() (any, error) {
+		return "ok", nil
+	}); err != nil {
+		t.Fatalf("RunTask com a API fora deveria seguir pelo outbox: %v", err)
+	}
+	if logs := srv.Logs(); len(logs) != 0 {
+		t.Fatalf("%d logs gravados com a API fora", len(logs))
+	}
+
+	// Cada Flush para no primeiro 503; as falhas acabam antes das tentativas
+	var err error
+	for range len(outage) + 1 {
+		if err = app.FlushOutbox(); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("outbox não esvaziou: %v", err)
+	}
+
+	logs := srv.Logs()
+	if len(logs) != 1 {
+		t.Fatalf("%d logs após o replay, esperado 1", len(logs))
+	}
+	if logs[0].Status() != "completed" {
+		t.Errorf("status = %s, esperado completed", logs[0].Status())
+	}
+	if tasks := srv.Tasks(); len(tasks) != 1 || logs[0].TaskID() != tasks[0].ID {
+		t.Errorf("log ligado à task %d, tasks = %+v", logs[0].TaskID(), tasks)
+	}
+}
@@ -1,8 +1,10 @@
 package botapp
 
 import (
+	"context"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,16 +12,43 @@ import (
 	"os/user"
 	"regexp"
 	"runtime"
-	"runtime/debug"
 	"strings"
+	"sync"
 	"time"
 	"net/url"
+
+	"github.com/botlorien/go-rpa-template/pkg/buildinfo"
 )
 
 type Config struct {
 	APIURL   string
 	User     string
 	Password string
+	// AuthMode escolhe a autenticação: basic (padrão), token, jwt ou none (só mTLS)
+	AuthMode      string
+	Token         string // Token do DRF (modo token)
+	TokenFile     string // Arquivo com o token, relido no 401 (rotação sem redeploy)
+	JWTTokenURL   string // Padrão: APIURL + /token/
+	JWTRefreshURL string // Padrão: APIURL + /token/refresh/
+	// Certificado cliente (mTLS) e CA do servidor. Funcionam com qualquer AuthMode.
+	TLSCertFile string
+	TLSKeyFile  string
+	TLSCAFile   string
+	// Auth sobrescreve o AuthMode com uma implementação própria
+	Auth Authenticator
+	// OutboxDir guarda em disco os logs que não puderam ser enviados (API fora do ar).
+	// Vazio desativa o outbox: nesse caso o histórico da execução se perde.
+	OutboxDir string
+	// MaxResultBytes limita o tamanho do result_data (0 = DefaultMaxResultBytes)
+	MaxResultBytes int
+	// HeartbeatInterval é de quanto em quanto tempo as tasks em andamento avisam que estão vivas
+	HeartbeatInterval time.Duration
+	// StaleAfter é quanto tempo sem heartbeat faz um log "started" ser considerado abandonado
+	StaleAfter time.Duration
+	// StatePollInterval é de quanto em quanto tempo a execução confere se o bot foi pausado/desativado
+	StatePollInterval time.Duration
+	// MaxArtifactBytes limita o tamanho dos arquivos anexados (0 = DefaultMaxArtifactBytes)
+	MaxArtifactBytes int64
 }
 
 type Client struct {
@@ -27,6 +56,49 @@ type Client struct {
 	BotInstance *Bot
 	BotName     string
 	HTTPClient  *http.Client
+	auth        Authenticator
+	outbox      *Outbox
+
+	ipOnce sync.Once // IP de saída até a API deste Client (outboundIP)
+	hostIP string
+}
+
+// APIError é uma resposta de erro (status >= 400) da API do BotApp
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API Error %d: %s", e.StatusCode, e.Body)
+}
+
+// resourceRef aponta para um recurso da API que pode ainda não existir no servidor:
+// com a API fora, ele é criado no outbox e identificado por um ref local até ser reenviado.
+type resourceRef struct {
+	ID  int
+	Ref string
+}
+
+// Value é o valor para usar em payloads (ID ou placeholder resolvido no replay)
+func (r resourceRef) Value() any {
+	if r.ID != 0 {
+		return r.ID
+	}
+	return refPlaceholder(r.Ref)
+}
+
+// Path monta o endpoint de detalhe do recurso (ex: /tasklog/12/)
+func (r resourceRef) Path(prefix string) string {
+	if r.ID != 0 {
+		return fmt.Sprintf("%s%d/", prefix, r.ID)
+	}
+	return prefix + refPlaceholder(r.Ref) + "/"
+}
+
+// Valid indica se o recurso foi criado (no servidor ou no outbox)
+func (r resourceRef) Valid() bool {
+	return r.ID != 0 || r.Ref != ""
 }
 
 // NewClient inicializa o cliente lendo variáveis de ambiente
@@ -38,10 +110,35 @@ func NewClient(cfg Config) (*Client, error) {
     // CORREÇÃO: Faça o Trim na variável 'cfg' ANTES de criar o Client
     cfg.APIURL = strings.TrimRight(cfg.APIURL, "/")
 
-    return &Client{
+    client := &Client{
         Config:     cfg, // Aqui você passa a config já ajustada
         HTTPClient: &http.Client{Timeout: 30 * time.Second},
-    }, nil
+    }
+
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		client.HTTPClient.Transport = transport
+	}
+
+	client.auth, err = newAuthenticator(cfg, client.HTTPClient)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.OutboxDir != "" {
+		outbox, err := NewOutbox(cfg.OutboxDir)
+		if err != nil {
+			return nil, err
+		}
+		client.outbox = outbox
+	}
+
+	return client, nil
 }
 
 // SetBot registra ou atualiza o Bot na API
@@ -63,6 +160,14 @@ func (c *Client) SetBot(name, description, version, department string) error {
 	// 1. Tenta buscar o bot
 	existingBot, err := c.searchBot(cleanedName)
 	if err != nil {
+		// API fora do ar: usa o último registro conhecido para o robô conseguir rodar offline
+		if c.outbox != nil && isRetryable(err) {
+			if cached := c.outbox.cachedBot(cleanedName); cached != nil {
+				fmt.Printf("⚠️ BotApp inacessível, usando registro em cache do bot '%s': %v\n", cleanedName, err)
+				c.BotInstance = cached
+				return nil
+			}
+		}
 		return err
 	}
 
@@ -92,105 +197,87 @@ func (c *Client) SetBot(name, description, version, department string) error {
 		c.BotInstance = &newBot
 	}
 
-	return nil
-}
-
-// RunTask é o wrapper (o "decorator") que envolve sua função
-// funcName: Nome da tarefa na dashboard
-// description: Descrição da tarefa
-// taskFunc: A função que contém sua lógica
-func (c *Client) RunTask(funcName, description string, taskFunc func() (any, error)) (any, error) {
-	if c.BotInstance == nil {
-		return nil, fmt.Errorf("bot não definido. Chame SetBot() antes")
+	if c.outbox != nil {
+		c.outbox.cacheBot(c.BotInstance)
+		// Aproveita que a API respondeu para reenviar o que ficou pendente de execuções anteriores
+		if err := c.FlushOutbox(); err != nil {
+			fmt.Printf("⚠️ Outbox ainda com eventos pendentes: %v\n", err)
+		}
 	}
-	if !c.BotInstance.IsActive {
-		return nil, fmt.Errorf("o bot '%s' está inativo", c.BotInstance.Name)
+
+	// Fecha os logs que um processo deste bot (em qualquer host) deixou "started" ao morrer.
+	// Roda depois do flush: um fim que estava no outbox tem prioridade sobre o "abandoned".
+	if err := c.reapStaleLogs(); err != nil {
+		fmt.Printf("⚠️ Erro ao verificar execuções abandonadas: %v\n", err)
 	}
 
-	// 1. Registra/Busca a Task na API
-	taskObj, err := c.ensureTask(funcName, description)
+	return nil
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("erro ao registrar task: %v", err)
-	}
+// Intervalos do replay do outbox
+const (
+	outboxPollInterval = 30 * time.Second
+	outboxMinBackoff   = 2 * time.Second
+	outboxMaxBackoff   = 5 * time.Minute
+)
 
-	// 2. Coleta dados do ambiente
-	envInfo := c.collectEnvInfo()
-	startTime := time.Now()
+// --- Métodos Privados Auxiliares ---
 
-	// 3. Cria Log (STARTED)
-	logPayload := envInfo
-	logPayload.TaskID = taskObj.ID
-	logPayload.Status = "started"
-	logPayload.StartTime = &startTime
-	// EndTime fica nil: com ponteiro, `omitempty` efetivamente omite do JSON.
-	
-	logResp, err := c.doRequest("POST", "/tasklog/", logPayload)
-	if err != nil {
-		fmt.Printf("⚠️ Erro ao criar log de início: %v\n", err)
-	}
-	
-	// Extrai ID do log criado para atualizar depois
-	var createdLog struct { ID int `json:"id"` }
-	_ = json.Unmarshal(logResp, &createdLog)
-	logID := createdLog.ID
-
-	// 4. Executa a função do usuário
-	var result any
-	var execErr error
-	var panicErr interface{}
-	
-	func() {
-		defer func() {
-			if r := recover(); r != nil {
-				panicErr = r // Captura panic (crash)
+// listAll lê todas as páginas de uma listagem do DRF. Aceita a lista pura (API sem paginação)
+// ou o envelope {"next": ..., "results": [...]}, seguindo o next até a última página.
+func listAll[T any](c *Client, endpoint string) ([]T, error) {
+	var all []T
+	seen := map[string]bool{}
+	for endpoint != "" && !seen[endpoint] {
+		seen[endpoint] = true
+		resp, err := c.doRequest("GET", endpoint, nil)
+		if err != nil {
+			return all, err
+		}
+
+		if trimmed := bytes.TrimSpace(resp); len(trimmed) > 0 && trimmed[0] == '[' {
+			var items []T
+			if err := json.Unmarshal(trimmed, &items); err != nil {
+				return all, err
 			}
-		}()
-		result, execErr = taskFunc()
-	}()
-
-	endTime := time.Now()
-	duration := formatDjangoDuration(endTime.Sub(startTime))
-
-	// 5. Prepara o Payload de Finalização (PATCH)
-	finalPayload := map[string]interface{}{
-		"end_time": endTime,
-		"duration": duration,
-	}
-
-	if panicErr != nil {
-		// Se deu Panic (Crash)
-		finalPayload["status"] = "failed"
-		finalPayload["error_message"] = fmt.Sprintf("PANIC: %v\nStack: %s", panicErr, string(debug.Stack()))
-		finalPayload["exception_type"] = "Panic"
-	} else if execErr != nil {
-		// Se a função retornou erro
-		finalPayload["status"] = "failed"
-		finalPayload["error_message"] = execErr.Error()
-		finalPayload["exception_type"] = "Error"
-	} else {
-		// Sucesso
-		finalPayload["status"] = "completed"
-		finalPayload["result_data"] = map[string]string{"return": fmt.Sprintf("%v", result)}
-	}
+			return append(all, items...), nil
+		}
 
-	// 6. Atualiza o Log
-	if logID != 0 {
-		_, err := c.doRequest("PATCH", fmt.Sprintf("/tasklog/%d/", logID), finalPayload)
-		if err != nil {
-			fmt.Printf("⚠️ Erro ao fechar log: %v\n", err)
+		var page struct {
+			Next    string `json:"next"`
+			Results []T    `json:"results"`
+		}
+		if err := json.Unmarshal(resp, &page); err != nil {
+			return all, err
+		}
+		all = append(all, page.Results...)
+		if endpoint, err = c.relativeEndpoint(page.Next); err != nil {
+			return all, err
 		}
 	}
+	return all, nil
+}
 
-	// Retorna o resultado original para o código chamador
-	if panicErr != nil {
-		panic(panicErr) // Relança o pânico localmente
+// relativeEndpoint transforma a URL absoluta do "next" do DRF num endpoint relativo ao APIURL
+func (c *Client) relativeEndpoint(next string) (string, error) {
+	if next == "" {
+		return "", nil
+	}
+	u, err := url.Parse(next)
+	if err != nil {
+		return "", fmt.Errorf("next inválido na paginação: %w", err)
+	}
+	base, err := url.Parse(c.Config.APIURL)
+	if err != nil {
+		return "", err
 	}
-	return result, execErr
+	endpoint := strings.TrimPrefix(u.Path, strings.TrimSuffix(base.Path, "/"))
+	if u.RawQuery != "" {
+		endpoint += "?" + u.RawQuery
+	}
+	return endpoint, nil
 }
 
-// --- Métodos Privados Auxiliares ---
-
 func (c *Client) searchBot(name string) (*Bot, error) {
 	resp, err := c.doRequest("GET", "/bots/?search="+url.QueryEscape(name), nil)
 	if err != nil {
@@ -208,10 +295,43 @@ func (c *Client) searchBot(name string) (*Bot, error) {
 	return nil, nil
 }
 
-func (c *Client) ensureTask(name, description string) (*Task, error) {
+// ensureTask busca/cria a Task. Com a API fora, usa o ID em cache ou,
+// se a Task nunca foi vista, agenda a criação no outbox e devolve um ref local.
+func (c *Client) ensureTask(name, description string) (resourceRef, error) {
+	botID := c.BotInstance.ID
+	task, err := c.ensureTaskForBot(botID, name, description)
+	if err == nil {
+		if c.outbox != nil {
+			c.outbox.cacheTask(botID, name, task.ID)
+		}
+		return resourceRef{ID: task.ID}, nil
+	}
+
+	if c.outbox == nil || !isRetryable(err) {
+		return resourceRef{}, err
+	}
+	if id, ok := c.outbox.cachedTask(botID, name); ok {
+		return resourceRef{ID: id}, nil
+	}
+
+	ref := newRef("task")
+	enqueueErr := c.outbox.Enqueue(outboxEvent{
+		Kind:     eventEnsureTask,
+		Ref:      ref,
+		BotID:    botID,
+		TaskName: name,
+		TaskDesc: description,
+	})
+	if enqueueErr != nil {
+		return resourceRef{}, fmt.Errorf("%v (e falha no outbox: %v)", err, enqueueErr)
+	}
+	return resourceRef{Ref: ref}, nil
+}
+
+func (c *Client) ensureTaskForBot(botID int, name, description string) (*Task, error) {
 	// Busca tasks existentes
 	safeName := url.QueryEscape(name)
-    resp, err := c.doRequest("GET", fmt.Sprintf("/tasks/?bot=%d&name=%s", c.BotInstance.ID, safeName), nil)
+    resp, err := c.doRequest("GET", fmt.Sprintf("/tasks/?bot=%d&name=%s", botID, safeName), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -221,7 +341,7 @@ func (c *Client) ensureTask(name, description string) (*Task, error) {
 	}
 
 	for _, t := range tasks {
-		if t.Name == name && t.BotID == c.BotInstance.ID {
+		if t.Name == name && t.BotID == botID {
 			// Update description if needed
 			if t.Description != description {
 				c.doRequest("PATCH", fmt.Sprintf("/tasks/%d/", t.ID), map[string]string{"description": description})
@@ -232,7 +352,7 @@ func (c *Client) ensureTask(name, description string) (*Task, error) {
 
 	// Create
 	newPayload := map[string]interface{}{
-		"bot":         c.BotInstance.ID,
+		"bot":         botID,
 		"name":        name,
 		"description": description,
 	}
@@ -245,7 +365,139 @@ func (c *Client) ensureTask(name, description string) (*Task, error) {
 	return &newTask, nil
 }
 
-func (c *Client) collectEnvInfo() LogPayload {
+// create faz um POST que cria um recurso. Com a API fora (e outbox ativo),
+// o POST é enfileirado e o recurso passa a ser identificado por um ref local.
+func (c *Client) create(endpoint string, payload any) (resourceRef, error) {
+	ref := newRef("res")
+	resp, err := c.sendEvent(outboxEvent{Kind: eventRequest, Method: "POST", Endpoint: endpoint, Ref: ref}, payload)
+	if err != nil {
+		return resourceRef{}, err
+	}
+	if resp == nil {
+		return resourceRef{Ref: ref}, nil // Foi para o outbox
+	}
+
+	var created struct{ ID int `json:"id"` }
+	if err := json.Unmarshal(resp, &created); err != nil {
+		return resourceRef{}, err
+	}
+	return resourceRef{ID: created.ID}, nil
+}
+
+// send faz uma chamada que altera estado (PATCH/POST) passando pelo outbox quando necessário.
+// Retorna resposta nil quando a chamada foi enfileirada.
+func (c *Client) send(method, endpoint string, payload any) ([]byte, error) {
+	return c.sendEvent(outboxEvent{Kind: eventRequest, Method: method, Endpoint: endpoint}, payload)
+}
+
+func (c *Client) sendEvent(ev outboxEvent, payload any) ([]byte, error) {
+	if c.outbox == nil {
+		return c.doRequest(ev.Method, ev.Endpoint, payload)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	ev.Payload = data
+
+	// Só envia direto se não há nada pendente na frente (a ordem importa)
+	// e se todos os refs do evento já têm ID no servidor.
+	if resolved, missing := c.outbox.resolveRefs(ev); missing == "" && c.outbox.Pending() == 0 {
+		resp, err := c.doRequest(resolved.Method, resolved.Endpoint, resolved.Payload)
+		if err == nil || !isRetryable(err) {
+			return resp, err
+		}
+		fmt.Printf("⚠️ BotApp inacessível, guardando %s %s no outbox: %v\n", ev.Method, ev.Endpoint, err)
+	}
+
+	if err := c.outbox.Enqueue(ev); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// FlushOutbox reenvia os eventos pendentes em ordem. Retorna erro se a API continuar fora.
+func (c *Client) FlushOutbox() error {
+	if c.outbox == nil {
+		return nil
+	}
+	return c.outbox.Flush(c.replay)
+}
+
+// RunOutbox reenvia o outbox em segundo plano até o ctx ser cancelado.
+// Em caso de falha espera com backoff exponencial; com sucesso, acorda a cada evento novo.
+func (c *Client) RunOutbox(ctx context.Context) {
+	if c.outbox == nil {
+		return
+	}
+
+	backoff := outboxMinBackoff
+	for {
+		err := c.FlushOutbox()
+
+		wait := outboxPollInterval
+		wake := c.outbox.wake
+		if err != nil {
+			wait = backoff
+			wake = nil // Em backoff, evento novo não antecipa a próxima tentativa
+			backoff *= 2
+			if backoff > outboxMaxBackoff {
+				backoff = outboxMaxBackoff
+			}
+		} else {
+			backoff = outboxMinBackoff
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// replay executa um evento do outbox contra a API
+func (c *Client) replay(ev outboxEvent) (int, error) {
+	switch ev.Kind {
+	case eventEnsureTask:
+		task, err := c.ensureTaskForBot(ev.BotID, ev.TaskName, ev.TaskDesc)
+		if err != nil {
+			return 0, err
+		}
+		c.outbox.cacheTask(ev.BotID, ev.TaskName, task.ID)
+		return task.ID, nil
+	case eventUpload:
+		artifact, err := decodeArtifact(c.doUpload(ev.Endpoint, ev.Payload, ev.File))
+		if err != nil {
+			return 0, err
+		}
+		return artifact.ID, nil
+	default:
+		var payload any
+		if len(ev.Payload) > 0 {
+			payload = ev.Payload
+		}
+		resp, err := c.doRequest(ev.Method, ev.Endpoint, payload)
+		if err != nil {
+			return 0, err
+		}
+		var created struct{ ID int `json:"id"` }
+		_ = json.Unmarshal(resp, &created)
+		return created.ID, nil
+	}
+}
+
+// newRef gera um identificador local único para um recurso criado offline
+func newRef(prefix string) string {
+	return fmt.Sprintf("%s-%d-%d", prefix, os.Getpid(), time.Now().UnixNano())
+}
+
+func (c *Client) collectEnvInfo(ctx context.Context) LogPayload {
 	hostname, _ := os.Hostname()
 	currentUser, _ := user.Current()
 	wd, _ := os.Getwd()
@@ -255,6 +507,10 @@ func (c *Client) collectEnvInfo() LogPayload {
 		username = currentUser.Username
 	}
 
+	build := buildinfo.Get()
+	trigger := TriggerFrom(ctx)
+	rt := DetectRuntime()
+
 	env := os.Getenv("BOTAPP_DEPLOY_ENV")
 	if env == "" {
 		env = "dev"
@@ -262,19 +518,36 @@ func (c *Client) collectEnvInfo() LogPayload {
 
 	return LogPayload{
 		HostName:      hostname,
-		HostIP:        "0.0.0.0", // Go requer pacotes externos para IP real confiável, deixei placeholder
+		HostIP:        c.outboundIP(),
 		UserLogin:     username,
 		BotDir:        wd,
 		OSPlatform:    runtime.GOOS + "/" + runtime.GOARCH,
-		PythonVersion: runtime.Version(), // Na verdade é Go Version
+		GoVersion:     build.GoVersion,
+		Commit:        build.Commit,
 		PID:           os.Getpid(),
 		Env:           env,
-		TriggerSource: "cli",
-		ManualTrigger: true,
+		TriggerSource:   trigger.Source,
+		TriggerIdentity: trigger.Identity,
+		ManualTrigger:   trigger.Manual(),
+		Runtime:         rt.Kind,
+		ContainerID:     rt.ContainerID,
+		PodName:         rt.PodName,
+		Namespace:       rt.Namespace,
+		ContainerImage:  rt.Image,
 	}
 }
 
+// Ping verifica se a API do BotApp está acessível (usado no readiness check)
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.doRequestContext(ctx, "GET", "/bots/?search="+url.QueryEscape(c.BotName), nil)
+	return err
+}
+
 func (c *Client) doRequest(method, endpoint string, data interface{}) ([]byte, error) {
+	return c.doRequestContext(context.Background(), method, endpoint, data)
+}
+
+func (c *Client) doRequestContext(ctx context.Context, method, endpoint string, data interface{}) ([]byte, error) {
 	url := c.Config.APIURL + endpoint
 	var body io.Reader
 
@@ -286,14 +559,50 @@ func (c *Client) doRequest(method, endpoint string, data interface{}) ([]byte, e
 		body = bytes.NewBuffer(jsonData)
 	}
 
-	req, err := http.NewRequest(method, url, body)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, err
 	}
-
-	req.SetBasicAuth(c.Config.User, c.Config.Password)
 	req.Header.Set("Content-Type", "application/json")
 
+	return c.do(req)
+}
+
+// do autentica e executa a requisição. Status >= 400 vira *APIError.
+// Num 401, se a credencial é renovável (JWT, token em arquivo), renova e tenta uma vez mais.
+func (c *Client) do(req *http.Request) ([]byte, error) {
+	respBody, err := c.roundTrip(req)
+
+	var apiErr *APIError
+	refresher, ok := c.auth.(Refresher)
+	if !ok || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		return respBody, err
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return respBody, err // Corpo já consumido e sem como recriar
+	}
+
+	if refreshErr := refresher.Refresh(req.Context()); refreshErr != nil {
+		return respBody, fmt.Errorf("%w (falha ao renovar credencial: %v)", err, refreshErr)
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	return c.roundTrip(retry)
+}
+
+func (c *Client) roundTrip(req *http.Request) ([]byte, error) {
+	auth := c.auth
+	if auth == nil {
+		auth = &BasicAuth{User: c.Config.User, Password: c.Config.Password}
+	}
+	if err := auth.Authenticate(req); err != nil {
+		return nil, err
+	}
+
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
@@ -302,7 +611,7 @@ func (c *Client) doRequest(method, endpoint string, data interface{}) ([]byte, e
 
 	respBody, err := io.ReadAll(resp.Body)
 	if resp.StatusCode >= 400 {
-		return respBody, fmt.Errorf("API Error %d: %s", resp.StatusCode, string(respBody))
+		return respBody, &APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
 	}
 
 	return respBody, err
@@ -0,0 +1,152 @@
+package botapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultStatePollInterval é de quanto em quanto tempo a task consulta o estado do bot na dashboard
+const DefaultStatePollInterval = 15 * time.Second
+
+// ErrBotDeactivated é a causa do cancelamento quando um operador desativa o bot no meio da execução
+var ErrBotDeactivated = errors.New("bot desativado na dashboard")
+
+// botControl guarda o estado de pausa de uma execução (compartilhado pela task raiz e seus Steps)
+type botControl struct {
+	mu       sync.Mutex
+	paused   bool
+	pauseCh  chan struct{} // Fechado enquanto o bot está pausado
+	resumeCh chan struct{} // Fechado enquanto o bot está rodando
+}
+
+func newBotControl() *botControl {
+	resume := make(chan struct{})
+	close(resume)
+	return &botControl{pauseCh: make(chan struct{}), resumeCh: resume}
+}
+
+func (b *botControl) setPaused(paused bool) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if paused == b.paused {
+		return false
+	}
+	b.paused = paused
+	if paused {
+		close(b.pauseCh)
+		b.resumeCh = make(chan struct{})
+	} else {
+		close(b.resumeCh)
+		b.pauseCh = make(chan struct{})
+	}
+	return true
+}
+
+func (b *botControl) channels() (pause, resume chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.pauseCh, b.resumeCh
+}
+
+func (c *Client) statePollInterval() time.Duration {
+	if c.Config.StatePollInterval > 0 {
+		return c.Config.StatePollInterval
+	}
+	return DefaultStatePollInterval
+}
+
+// fetchBot lê o estado atual do bot na API (is_active / is_paused)
+func (c *Client) fetchBot(ctx context.Context) (*Bot, error) {
+	resp, err := c.doRequestContext(ctx, "GET", fmt.Sprintf("/bots/%d/", c.BotInstance.ID), nil)
+	if err != nil {
+		return nil, err
+	}
+	var bot Bot
+	if err := json.Unmarshal(resp, &bot); err != nil {
+		return nil, err
+	}
+	return &bot, nil
+}
+
+// watchBot consulta o bot durante a execução: desativado cancela o ctx da task,
+// pausado fecha o canal de Paused() até o operador retomar.
+// Com a API fora, mantém o último estado conhecido (não derruba a execução).
+func (c *Client) watchBot(ctx context.Context, ctrl *botControl, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(c.statePollInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		bot, err := c.fetchBot(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				fmt.Printf("⚠️ Erro ao consultar estado do bot: %v\n", err)
+			}
+			continue
+		}
+		if !bot.IsActive {
+			fmt.Printf("⛔ Bot '%s' desativado na dashboard, interrompendo execução\n", bot.Name)
+			cancel(ErrBotDeactivated)
+			return
+		}
+		if ctrl.setPaused(bot.IsPaused) {
+			if bot.IsPaused {
+				fmt.Printf("⏸️ Bot '%s' pausado na dashboard, aguardando no próximo checkpoint\n", bot.Name)
+			} else {
+				fmt.Printf("▶️ Bot '%s' retomado na dashboard\n", bot.Name)
+			}
+		}
+	}
+}
+
+// Paused devolve um canal que fecha quando o bot é pausado na dashboard.
+// Sem BotApp (LocalTask) o canal nunca fecha.
+func (t *TaskContext) Paused() <-chan struct{} {
+	if t.ctrl == nil {
+		return nil
+	}
+	pause, _ := t.ctrl.channels()
+	return pause
+}
+
+// Checkpoint é um ponto seguro para parar ou esperar: chame entre itens de loops longos.
+// Retorna erro se a execução foi cancelada (shutdown ou bot desativado) e
+// bloqueia enquanto o bot estiver pausado.
+func (t *TaskContext) Checkpoint() error {
+	if err := contextErr(t.ctx); err != nil {
+		return err
+	}
+	if t.ctrl == nil {
+		return nil
+	}
+
+	_, resume := t.ctrl.channels()
+	select {
+	case <-resume:
+		return nil
+	case <-t.ctx.Done():
+		return contextErr(t.ctx)
+	}
+}
+
+// contextErr devolve o erro do ctx incluindo a causa (ex: ErrBotDeactivated),
+// mantendo errors.Is(err, context.Canceled) verdadeiro
+func contextErr(ctx context.Context) error {
+	err := ctx.Err()
+	if err == nil {
+		return nil
+	}
+	if cause := context.Cause(ctx); cause != nil && cause != err {
+		return fmt.Errorf("%w: %w", err, cause)
+	}
+	return err
+}
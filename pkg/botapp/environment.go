@@ -0,0 +1,206 @@
+package botapp
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Origens de execução aceitas pela dashboard (trigger_source)
+const (
+	TriggerAPI       = "api"
+	TriggerCLI       = "cli"
+	TriggerScheduler = "scheduler"
+	TriggerWebhook   = "webhook"
+)
+
+// Trigger diz quem pediu a execução. Vai para o log da dashboard e permite separar
+// execuções agendadas das manuais.
+type Trigger struct {
+	Source   string // api, cli, scheduler, webhook
+	Identity string // Quem pediu: usuário, IP do cliente, nome do job...
+}
+
+// Manual indica se a execução foi disparada por uma pessoa (e não por agendamento/integração)
+func (t Trigger) Manual() bool {
+	return t.Source == TriggerAPI || t.Source == TriggerCLI
+}
+
+// ValidTrigger diz se a origem é uma das conhecidas
+func ValidTrigger(source string) bool {
+	switch source {
+	case TriggerAPI, TriggerCLI, TriggerScheduler, TriggerWebhook:
+		return true
+	}
+	return false
+}
+
+type triggerKey struct{}
+
+// WithTrigger anexa a origem da execução ao ctx (o handler HTTP e a CLI fazem isso)
+func WithTrigger(ctx context.Context, t Trigger) context.Context {
+	return context.WithValue(ctx, triggerKey{}, t)
+}
+
+// TriggerFrom lê a origem do ctx. Sem ela, assume execução manual pela CLI (comportamento antigo).
+func TriggerFrom(ctx context.Context) Trigger {
+	if ctx != nil {
+		if t, ok := ctx.Value(triggerKey{}).(Trigger); ok && t.Source != "" {
+			return t
+		}
+	}
+	return Trigger{Source: TriggerCLI}
+}
+
+// Runtime é onde o processo está rodando
+type Runtime struct {
+	Kind        string // host, container, kubernetes
+	ContainerID string
+	PodName     string
+	Namespace   string
+	Image       string
+}
+
+const (
+	RuntimeHost       = "host"
+	RuntimeContainer  = "container"
+	RuntimeKubernetes = "kubernetes"
+)
+
+// Caminhos lidos na detecção
+const (
+	dockerEnvPath     = "/.dockerenv"
+	cgroupPath        = "/proc/self/cgroup"
+	mountInfoPath     = "/proc/self/mountinfo"
+	k8sNamespacePath  = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+	containerIDLength = 64
+)
+
+var (
+	runtimeOnce sync.Once
+	runtimeInfo Runtime
+)
+
+// DetectRuntime descobre se o processo roda num container/pod.
+// Nome do pod, namespace e imagem vêm das variáveis da Downward API
+// (POD_NAME, POD_NAMESPACE, CONTAINER_IMAGE), com fallback no hostname e no service account.
+func DetectRuntime() Runtime {
+	runtimeOnce.Do(func() {
+		runtimeInfo = detectRuntime()
+	})
+	return runtimeInfo
+}
+
+func detectRuntime() Runtime {
+	rt := Runtime{Kind: RuntimeHost, Image: os.Getenv("CONTAINER_IMAGE")}
+
+	rt.ContainerID = containerID()
+	if _, err := os.Stat(dockerEnvPath); err == nil || rt.ContainerID != "" || os.Getenv("container") != "" {
+		rt.Kind = RuntimeContainer
+	}
+
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		rt.Kind = RuntimeKubernetes
+		rt.PodName = os.Getenv("POD_NAME")
+		if rt.PodName == "" {
+			rt.PodName, _ = os.Hostname() // No k8s o hostname é o nome do pod
+		}
+		rt.Namespace = os.Getenv("POD_NAMESPACE")
+		if rt.Namespace == "" {
+			if data, err := os.ReadFile(k8sNamespacePath); err == nil {
+				rt.Namespace = strings.TrimSpace(string(data))
+			}
+		}
+	}
+	return rt
+}
+
+// containerID procura o ID do container no cgroup (v1) ou no mountinfo (cgroup v2)
+func containerID() string {
+	for _, path := range []string{cgroupPath, mountInfoPath} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if id := findContainerID(string(data)); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// findContainerID acha o primeiro ID hexadecimal de 64 caracteres num caminho de container
+// (ex: /kubepods/.../cri-containerd-<id>.scope, /docker/<id>, /var/lib/docker/containers/<id>/hostname)
+func findContainerID(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.Contains(line, "docker") && !strings.Contains(line, "kubepods") &&
+			!strings.Contains(line, "containerd") && !strings.Contains(line, "libpod") {
+			continue
+		}
+		for _, field := range strings.FieldsFunc(line, func(r rune) bool {
+			return r == '/' || r == '-' || r == '.' || r == ':' || r == ' '
+		}) {
+			if len(field) == containerIDLength && isHex(field) {
+				return field
+			}
+		}
+	}
+	return ""
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// outboundIP descobre o IP da interface usada para sair para a rede (a rota até a dashboard).
+// Dial UDP não manda pacote nenhum: só pede ao kernel para escolher a rota.
+// O cache é por Client: outro Client pode falar com um BotApp em outra rede (outra rota).
+func (c *Client) outboundIP() string {
+	c.ipOnce.Do(func() {
+		targets := []string{"8.8.8.8:80"}
+		if u, err := url.Parse(c.Config.APIURL); err == nil && u.Hostname() != "" {
+			port := u.Port()
+			if port == "" {
+				port = "443"
+			}
+			targets = append([]string{net.JoinHostPort(u.Hostname(), port)}, targets...)
+		}
+
+		for _, target := range targets {
+			conn, err := net.Dial("udp", target)
+			if err != nil {
+				continue
+			}
+			addr, ok := conn.LocalAddr().(*net.UDPAddr)
+			conn.Close()
+			if ok && !addr.IP.IsUnspecified() {
+				c.hostIP = addr.IP.String()
+				return
+			}
+		}
+		c.hostIP = firstInterfaceIP()
+	})
+	return c.hostIP
+}
+
+// firstInterfaceIP é o fallback sem rota nenhuma (máquina isolada): primeiro IPv4 que não é loopback
+func firstInterfaceIP() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "0.0.0.0"
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			return ipNet.IP.String()
+		}
+	}
+	return "0.0.0.0"
+}
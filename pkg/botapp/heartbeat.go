@@ -0,0 +1,127 @@
+package botapp
+
+import (
+	"fmt"
+	"time"
+)
+
+// Padrões do heartbeat (sobrescritos por Config.HeartbeatInterval / Config.StaleAfter)
+const (
+	DefaultHeartbeatInterval = 30 * time.Second
+	DefaultStaleAfter        = 5 * time.Minute
+)
+
+// statusAbandoned é o status dado aos logs cujo processo morreu sem fechá-los
+const statusAbandoned = "abandoned"
+
+func (c *Client) heartbeatInterval() time.Duration {
+	if c.Config.HeartbeatInterval > 0 {
+		return c.Config.HeartbeatInterval
+	}
+	return DefaultHeartbeatInterval
+}
+
+func (c *Client) staleAfter() time.Duration {
+	if c.Config.StaleAfter > 0 {
+		return c.Config.StaleAfter
+	}
+	return DefaultStaleAfter
+}
+
+// startHeartbeat manda last_heartbeat periodicamente enquanto a task não termina.
+// Se o processo morrer (OOM, container derrubado), o heartbeat para e o próximo
+// SetBot deste bot (em qualquer host) fecha o log como "abandoned".
+func (t *TaskContext) startHeartbeat() {
+	t.stopBeat = make(chan struct{})
+	interval := t.client.heartbeatInterval()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.stopBeat:
+				return
+			case now := <-ticker.C:
+				t.beat(now)
+			}
+		}
+	}()
+}
+
+// beat envia um heartbeat. Heartbeats não passam pelo outbox: um atrasado não serve
+// para nada e encheria a fila enquanto a API está fora. Se o log ainda não chegou
+// ao servidor, só pula.
+func (t *TaskContext) beat(now time.Time) {
+	id := t.log.ID
+	if id == 0 && t.client.outbox != nil {
+		id, _ = t.client.outbox.Resolve(t.log.Ref)
+	}
+	if id == 0 {
+		return
+	}
+
+	payload := map[string]interface{}{"last_heartbeat": now}
+	if _, err := t.client.doRequest("PATCH", fmt.Sprintf("/tasklog/%d/", id), payload); err != nil {
+		fmt.Printf("⚠️ Erro ao enviar heartbeat de '%s': %v\n", t.path, err)
+	}
+}
+
+func (t *TaskContext) stopHeartbeat() {
+	if t.stopBeat != nil {
+		close(t.stopBeat)
+	}
+}
+
+// reapStaleLogs fecha como "abandoned" os logs deste bot que ficaram "started" sem
+// heartbeat recente (o processo que os abriu morreu no meio). Vale para qualquer host:
+// no Kubernetes o pod que substitui o morto tem outro hostname, e um processo vivo
+// manda heartbeat bem antes do StaleAfter.
+func (c *Client) reapStaleLogs() error {
+	tasks, err := listAll[Task](c, fmt.Sprintf("/tasks/?bot=%d", c.BotInstance.ID))
+	if err != nil {
+		return err
+	}
+	botTasks := make(map[int]bool, len(tasks))
+	for _, t := range tasks {
+		if t.BotID == c.BotInstance.ID {
+			botTasks[t.ID] = true
+		}
+	}
+
+	// Sem filtro de last_heartbeat na API: log cujo processo morreu antes do 1º heartbeat
+	// tem last_heartbeat nulo e ficaria de fora. A idade é conferida aqui (LastSeen).
+	logs, err := listAll[TaskLog](c, fmt.Sprintf("/tasklog/?bot=%d&status=started", c.BotInstance.ID))
+	if err != nil {
+		return err
+	}
+
+	// A API pode ignorar algum filtro: confere tudo aqui antes de fechar
+	staleAfter := c.staleAfter()
+	now := time.Now()
+	for _, l := range logs {
+		if l.Status != "started" || !botTasks[l.TaskID] {
+			continue
+		}
+		lastSeen := l.LastSeen()
+		if lastSeen.IsZero() || now.Sub(lastSeen) < staleAfter {
+			continue
+		}
+
+		payload := map[string]interface{}{
+			"status":         statusAbandoned,
+			"end_time":       lastSeen,
+			"error_message":  fmt.Sprintf("Execução abandonada: sem heartbeat desde %s (processo %d em %s provavelmente foi encerrado)", lastSeen.Format(time.RFC3339), l.PID, l.HostName),
+			"exception_type": "Abandoned",
+		}
+		if l.StartTime != nil {
+			payload["duration"] = formatDjangoDuration(lastSeen.Sub(*l.StartTime))
+		}
+		if _, err := c.doRequest("PATCH", fmt.Sprintf("/tasklog/%d/", l.ID), payload); err != nil {
+			fmt.Printf("⚠️ Erro ao fechar log abandonado %d: %v\n", l.ID, err)
+			continue
+		}
+		fmt.Printf("🧹 Log %d fechado como abandonado (sem heartbeat desde %s)\n", l.ID, lastSeen.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+
+package botapp
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile pega um lock exclusivo (flock) no arquivo, esperando se outro processo estiver com ele.
+// O lock cai sozinho se o processo morrer.
+func lockFile(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,28 @@
+//go:build windows
+
+package botapp
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile pega um lock exclusivo (LockFileEx) no arquivo, esperando se outro processo estiver com ele.
+// O lock cai sozinho se o processo morrer.
+func lockFile(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	handle := windows.Handle(f.Fd())
+	ol := new(windows.Overlapped)
+	if err := windows.LockFileEx(handle, windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, ol); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		_ = windows.UnlockFileEx(handle, 0, 1, 0, ol)
+		f.Close()
+	}, nil
+}
@@ -10,6 +10,7 @@ type Bot struct {
 	Version     string `json:"version"`
 	Department  string `json:"department"`
 	IsActive    bool   `json:"is_active"`
+	IsPaused    bool   `json:"is_paused"`
 }
 
 // Task representa a tarefa registrada
@@ -28,22 +29,54 @@ type Task struct {
 // "Datetime value out of range" (DRF converte pra America/Cuiaba e
 // estoura em ano 0). Com ponteiro, nil é realmente omitido.
 type LogPayload struct {
-	TaskID        int        `json:"task,omitempty"`
+	TaskID          any        `json:"task,omitempty"` // ID da Task ou "{{ref:xxx}}" se ela foi criada offline
+	Status          string     `json:"status"`
+	StartTime       *time.Time `json:"start_time,omitempty"`
+	EndTime         *time.Time `json:"end_time,omitempty"`
+	Duration        string     `json:"duration,omitempty"` // API espera string no formato de tempo do Django/Python
+	HostIP          string     `json:"host_ip"`
+	HostName        string     `json:"host_name"`
+	UserLogin       string     `json:"user_login"`
+	BotDir          string     `json:"bot_dir"`
+	OSPlatform      string     `json:"os_platform"`
+	GoVersion       string     `json:"go_version"`
+	Commit          string     `json:"commit,omitempty"` // Commit do build (vcs.revision ou ldflags)
+	PID             int        `json:"pid"`
+	Env             string     `json:"env"`
+	TriggerSource   string     `json:"trigger_source"`             // api, cli, scheduler, webhook
+	TriggerIdentity string     `json:"trigger_identity,omitempty"` // Quem pediu (usuário, IP, job)
+	ManualTrigger   bool       `json:"manual_trigger"`
+	Runtime         string     `json:"runtime,omitempty"` // host, container, kubernetes
+	ContainerID     string     `json:"container_id,omitempty"`
+	PodName         string     `json:"pod_name,omitempty"`
+	Namespace       string     `json:"namespace,omitempty"`
+	ContainerImage  string     `json:"container_image,omitempty"`
+	ResultData      any        `json:"result_data,omitempty"`
+	ErrorMessage    string     `json:"error_message,omitempty"`
+	ExceptionType   string     `json:"exception_type,omitempty"`
+	ParentLog       any        `json:"parent_log,omitempty"` // Log da task pai quando é um Step (ID ou "{{ref:xxx}}")
+	StepPath        string     `json:"step_path,omitempty"`  // Ex: "Execução Geral/Download"
+	LastHeartbeat   *time.Time `json:"last_heartbeat,omitempty"`
+}
+
+// TaskLog é um log já gravado na API (usado para achar execuções abandonadas)
+type TaskLog struct {
+	ID            int        `json:"id"`
+	TaskID        int        `json:"task"`
 	Status        string     `json:"status"`
-	StartTime     *time.Time `json:"start_time,omitempty"`
-	EndTime       *time.Time `json:"end_time,omitempty"`
-	Duration      string     `json:"duration,omitempty"` // API espera string no formato de tempo do Django/Python
-	HostIP        string     `json:"host_ip"`
+	StartTime     *time.Time `json:"start_time"`
+	LastHeartbeat *time.Time `json:"last_heartbeat"`
 	HostName      string     `json:"host_name"`
-	UserLogin     string     `json:"user_login"`
-	BotDir        string     `json:"bot_dir"`
-	OSPlatform    string     `json:"os_platform"`
-	PythonVersion string     `json:"python_version"` // Usaremos GoVersion aqui
 	PID           int        `json:"pid"`
-	Env           string     `json:"env"`
-	TriggerSource string     `json:"trigger_source"`
-	ManualTrigger bool       `json:"manual_trigger"`
-	ResultData    any        `json:"result_data,omitempty"`
-	ErrorMessage  string     `json:"error_message,omitempty"`
-	ExceptionType string     `json:"exception_type,omitempty"`
-}
\ No newline at end of file
+}
+
+// LastSeen é o último sinal de vida do log (heartbeat ou, sem ele, o início)
+func (l TaskLog) LastSeen() time.Time {
+	if l.LastHeartbeat != nil {
+		return *l.LastHeartbeat
+	}
+	if l.StartTime != nil {
+		return *l.StartTime
+	}
+	return time.Time{}
+}
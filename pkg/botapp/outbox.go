@@ -0,0 +1,486 @@
+package botapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tipos de evento do outbox
+const (
+	eventRequest    = "request"     // Chamada HTTP comum (POST/PATCH)
+	eventEnsureTask = "ensure_task" // Busca/cria a Task (não dá pra saber offline se ela já existe)
+	eventUpload     = "upload"      // Upload multipart de artefato (arquivo copiado para files/)
+)
+
+// refPattern casa os placeholders "{{ref:xxx}}" usados enquanto o recurso ainda não tem ID no servidor
+var refPattern = regexp.MustCompile(`\{\{ref:([a-zA-Z0-9_-]+)\}\}`)
+
+// quotedRefPattern casa o placeholder como valor JSON inteiro ("task": "{{ref:xxx}}"),
+// que no replay vira número e não string
+var quotedRefPattern = regexp.MustCompile(`"\{\{ref:([a-zA-Z0-9_-]+)\}\}"`)
+
+// refPlaceholder gera o placeholder de um recurso criado offline
+func refPlaceholder(ref string) string {
+	return "{{ref:" + ref + "}}"
+}
+
+// outboxEvent é uma chamada pendente, persistida em disco até o servidor confirmar
+type outboxEvent struct {
+	Seq       uint64          `json:"seq"`
+	Kind      string          `json:"kind"`
+	Method    string          `json:"method,omitempty"`
+	Endpoint  string          `json:"endpoint,omitempty"` // Pode conter {{ref:xxx}}
+	Payload   json.RawMessage `json:"payload,omitempty"`  // Pode conter "{{ref:xxx}}"
+	Ref       string          `json:"ref,omitempty"`      // O ID retornado pelo servidor vira o valor deste ref
+	BotID     int             `json:"bot_id,omitempty"`
+	TaskName  string          `json:"task_name,omitempty"`
+	TaskDesc  string          `json:"task_description,omitempty"`
+	File      string          `json:"file,omitempty"` // Cópia do artefato dentro do outbox
+	Attempts  int             `json:"attempts"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// outboxState é o que precisa sobreviver entre execuções além dos eventos
+type outboxState struct {
+	Seq   uint64          `json:"seq"`
+	Refs  map[string]int  `json:"refs"`  // ref local -> ID no servidor
+	Dead  map[string]bool `json:"dead"`  // refs cujo POST foi rejeitado (dependentes vão junto)
+	Tasks map[string]int  `json:"tasks"` // "botID:nome" -> ID da Task (cache para rodar offline)
+	Bot   *Bot            `json:"bot,omitempty"`
+}
+
+// replayFunc executa um evento contra a API. Retorna o ID criado (quando houver).
+type replayFunc func(ev outboxEvent) (int, error)
+
+// Outbox guarda em disco as chamadas ao BotApp que não puderam ser entregues
+// e as reenvia na ordem em que aconteceram quando a API volta.
+//
+// Layout do diretório:
+//
+//	state.json          refs resolvidos, cache de tasks/bot e sequência
+//	events/<seq>.json   eventos pendentes (nome ordenável = ordem de envio)
+//	dead/<seq>.json     eventos rejeitados pela API (4xx), para análise manual
+//	files/              cópias dos artefatos com upload pendente
+//	state.lock          lock de arquivo da sequência/refs (API e CLI podem dividir o outbox)
+//	flush.lock          lock de arquivo do replay: só um processo reenvia por vez
+type Outbox struct {
+	dir string
+
+	mu    sync.Mutex
+	state outboxState
+
+	flushMu sync.Mutex // Só um replay por vez
+	wake    chan struct{}
+}
+
+// NewOutbox abre (ou cria) o outbox no diretório informado
+func NewOutbox(dir string) (*Outbox, error) {
+	for _, sub := range []string{"events", "dead", "files"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("falha ao criar outbox em %s: %w", dir, err)
+		}
+	}
+
+	o := &Outbox{
+		dir:  dir,
+		wake: make(chan struct{}, 1),
+		state: outboxState{
+			Refs:  map[string]int{},
+			Dead:  map[string]bool{},
+			Tasks: map[string]int{},
+		},
+	}
+
+	if err := o.loadStateLocked(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// loadStateLocked relê o state.json do disco (o.mu travado). Outro processo usando o mesmo
+// outbox pode ter avançado a sequência ou resolvido refs desde a última leitura.
+func (o *Outbox) loadStateLocked() error {
+	state := outboxState{}
+	data, err := os.ReadFile(o.statePath())
+	if err == nil {
+		if err := json.Unmarshal(data, &state); err != nil {
+			return fmt.Errorf("state.json do outbox corrompido: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	// Garante que a sequência nunca volta atrás (ex: state.json apagado na mão)
+	state.Seq = max(state.Seq, o.state.Seq)
+	events, err := o.list()
+	if err != nil {
+		return err
+	}
+	for _, name := range events {
+		if seq, err := strconv.ParseUint(strings.TrimSuffix(name, ".json"), 10, 64); err == nil && seq > state.Seq {
+			state.Seq = seq
+		}
+	}
+	if state.Refs == nil {
+		state.Refs = map[string]int{}
+	}
+	if state.Dead == nil {
+		state.Dead = map[string]bool{}
+	}
+	if state.Tasks == nil {
+		state.Tasks = map[string]int{}
+	}
+	o.state = state
+	return nil
+}
+
+// updateStateLocked relê o state, aplica fn e grava de volta, tudo sob o state.lock (o.mu travado).
+// Sem o lock, a API e a CLI gerariam a mesma sequência e um sobrescreveria o evento do outro.
+func (o *Outbox) updateStateLocked(fn func() error) error {
+	unlock, err := lockFile(filepath.Join(o.dir, "state.lock"))
+	if err != nil {
+		return fmt.Errorf("falha ao travar o outbox: %w", err)
+	}
+	defer unlock()
+
+	if err := o.loadStateLocked(); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return o.saveStateLocked()
+}
+
+// Enqueue persiste um evento no fim da fila
+func (o *Outbox) Enqueue(ev outboxEvent) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	err := o.updateStateLocked(func() error {
+		o.state.Seq++
+		ev.Seq = o.state.Seq
+		if ev.CreatedAt.IsZero() {
+			ev.CreatedAt = time.Now()
+		}
+		return writeJSONAtomic(o.eventPath(ev.Seq), ev)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Acorda o replayer sem bloquear
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Pending retorna quantos eventos aguardam envio
+func (o *Outbox) Pending() int {
+	names, err := o.list()
+	if err != nil {
+		return 0
+	}
+	return len(names)
+}
+
+// StoreFile copia um arquivo para dentro do outbox e retorna o caminho da cópia
+func (o *Outbox) StoreFile(src string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	dst := filepath.Join(o.dir, "files", newRef("file")+"-"+filepath.Base(src))
+	out, err := os.Create(dst)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return "", err
+	}
+	return dst, out.Close()
+}
+
+// Resolve retorna o ID do servidor para um ref local, se já foi resolvido
+func (o *Outbox) Resolve(ref string) (int, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	id, ok := o.state.Refs[ref]
+	return id, ok
+}
+
+// Flush reenvia os eventos pendentes em ordem. Para no primeiro erro de conectividade
+// (a ordem importa: o PATCH de fim não pode passar na frente do POST de início).
+// Se outro processo (API e CLI no mesmo outbox) estiver reenviando, espera ele terminar:
+// dois replays do mesmo evento criariam task logs duplicados.
+func (o *Outbox) Flush(replay replayFunc) error {
+	o.flushMu.Lock()
+	defer o.flushMu.Unlock()
+
+	unlock, err := lockFile(filepath.Join(o.dir, "flush.lock"))
+	if err != nil {
+		return fmt.Errorf("falha ao travar o outbox: %w", err)
+	}
+	defer unlock()
+
+	// Os refs resolvidos pelo replay do outro processo estão no disco
+	o.mu.Lock()
+	err = o.loadStateLocked()
+	o.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	names, err := o.list()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		ev, err := o.read(name)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			// Arquivo corrompido não pode travar a fila
+			o.moveToDead(name, nil)
+			continue
+		}
+
+		resolved, missing := o.resolveRefs(ev)
+		if missing != "" {
+			if o.isDead(missing) {
+				// O recurso do qual este evento depende foi rejeitado: descarta junto
+				o.moveToDead(name, &ev)
+				continue
+			}
+			// Não deveria acontecer (eventos são processados em ordem), mas não arriscamos
+			return fmt.Errorf("outbox: ref %s ainda não resolvido", missing)
+		}
+
+		id, err := replay(resolved)
+		if err != nil {
+			if isRetryable(err) {
+				ev.Attempts++
+				_ = writeJSONAtomic(filepath.Join(o.dir, "events", name), ev)
+				return err
+			}
+			// 4xx: a API nunca vai aceitar esse payload, não adianta repetir
+			if ev.Ref != "" {
+				o.markDead(ev.Ref)
+			}
+			o.moveToDead(name, &ev)
+			continue
+		}
+
+		if ev.Ref != "" {
+			if id == 0 {
+				// Sem ID na resposta os dependentes nunca vão resolver: descarta-os também
+				o.markDead(ev.Ref)
+			} else if err := o.setRef(ev.Ref, id); err != nil {
+				return err
+			}
+		}
+		if ev.File != "" {
+			_ = os.Remove(ev.File)
+		}
+		if err := os.Remove(filepath.Join(o.dir, "events", name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveRefs troca os placeholders pelos IDs já conhecidos.
+// Retorna o nome do primeiro ref ainda não resolvido, se houver.
+func (o *Outbox) resolveRefs(ev outboxEvent) (outboxEvent, string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	missing := ""
+	replace := func(pattern *regexp.Regexp, s string) string {
+		return pattern.ReplaceAllStringFunc(s, func(m string) string {
+			ref := pattern.FindStringSubmatch(m)[1]
+			id, ok := o.state.Refs[ref]
+			if !ok {
+				if missing == "" {
+					missing = ref
+				}
+				return m
+			}
+			return strconv.Itoa(id)
+		})
+	}
+
+	ev.Endpoint = replace(refPattern, ev.Endpoint)
+	if len(ev.Payload) > 0 {
+		payload := replace(quotedRefPattern, string(ev.Payload))
+		ev.Payload = json.RawMessage(replace(refPattern, payload))
+	}
+	return ev, missing
+}
+
+func (o *Outbox) setRef(ref string, id int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.updateStateLocked(func() error {
+		o.state.Refs[ref] = id
+		return nil
+	})
+}
+
+func (o *Outbox) markDead(ref string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	_ = o.updateStateLocked(func() error {
+		o.state.Dead[ref] = true
+		return nil
+	})
+}
+
+func (o *Outbox) isDead(ref string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.state.Dead[ref]
+}
+
+// cachedTask / cacheTask guardam o ID das Tasks para o RunTask funcionar com a API fora
+func (o *Outbox) cachedTask(botID int, name string) (int, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	id, ok := o.state.Tasks[taskKey(botID, name)]
+	return id, ok
+}
+
+func (o *Outbox) cacheTask(botID int, name string, id int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.state.Tasks[taskKey(botID, name)] == id {
+		return
+	}
+	_ = o.updateStateLocked(func() error {
+		o.state.Tasks[taskKey(botID, name)] = id
+		return nil
+	})
+}
+
+// cachedBot / cacheBot guardam o último Bot registrado com sucesso
+func (o *Outbox) cachedBot(name string) *Bot {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.state.Bot != nil && o.state.Bot.Name == name {
+		bot := *o.state.Bot
+		return &bot
+	}
+	return nil
+}
+
+func (o *Outbox) cacheBot(bot *Bot) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	copyBot := *bot
+	_ = o.updateStateLocked(func() error {
+		o.state.Bot = &copyBot
+		return nil
+	})
+}
+
+func taskKey(botID int, name string) string {
+	return strconv.Itoa(botID) + ":" + name
+}
+
+func (o *Outbox) list() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(o.dir, "events"))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names) // Nomes com zero à esquerda: ordem alfabética = ordem de criação
+	return names, nil
+}
+
+func (o *Outbox) read(name string) (outboxEvent, error) {
+	var ev outboxEvent
+	data, err := os.ReadFile(filepath.Join(o.dir, "events", name))
+	if err != nil {
+		return ev, err
+	}
+	err = json.Unmarshal(data, &ev)
+	return ev, err
+}
+
+func (o *Outbox) moveToDead(name string, ev *outboxEvent) {
+	src := filepath.Join(o.dir, "events", name)
+	dst := filepath.Join(o.dir, "dead", name)
+	if err := os.Rename(src, dst); err != nil {
+		_ = os.Remove(src)
+	}
+	if ev != nil {
+		// A cópia do artefato não serve para nada sem o evento na fila
+		if ev.File != "" {
+			_ = os.Remove(ev.File)
+		}
+		fmt.Printf("⚠️ [Outbox] Evento %d (%s %s) descartado pela API, movido para dead/\n", ev.Seq, ev.Method, ev.Endpoint)
+	}
+}
+
+func (o *Outbox) eventPath(seq uint64) string {
+	return filepath.Join(o.dir, "events", fmt.Sprintf("%020d.json", seq))
+}
+
+func (o *Outbox) statePath() string {
+	return filepath.Join(o.dir, "state.json")
+}
+
+func (o *Outbox) saveStateLocked() error {
+	return writeJSONAtomic(o.statePath(), o.state)
+}
+
+// writeJSONAtomic grava num arquivo temporário e renomeia: um crash no meio não corrompe o original
+func writeJSONAtomic(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// isRetryable diz se vale a pena tentar de novo mais tarde:
+// erro de rede, 5xx e 429 sim; 4xx (payload inválido, não encontrado) não.
+// Arquivo de artefato sumido também não: nenhuma nova tentativa vai achá-lo.
+func isRetryable(err error) bool {
+	if errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500 || apiErr.StatusCode == 429
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package botapp
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestResolveRefs(t *testing.T) {
+	tests := []struct {
+		name         string
+		endpoint     string
+		payload      string
+		wantEndpoint string
+		wantPayload  string
+		wantMissing  string
+	}{
+		{
+			name:         "endpoint",
+			endpoint:     "/tasklog/{{ref:log1}}/",
+			wantEndpoint: "/tasklog/42/",
+		},
+		{
+			name:        "valor JSON inteiro vira número",
+			payload:     `{"task":"{{ref:task1}}","parent_log":"{{ref:log1}}"}`,
+			wantPayload: `{"task":7,"parent_log":42}`,
+		},
+		{
+			name:        "dentro de texto continua texto",
+			payload:     `{"message":"log {{ref:log1}} finalizado"}`,
+			wantPayload: `{"message":"log 42 finalizado"}`,
+		},
+		{
+			name:         "ref desconhecido fica como está",
+			endpoint:     "/tasklog/{{ref:novo}}/",
+			payload:      `{"task":"{{ref:task1}}"}`,
+			wantEndpoint: "/tasklog/{{ref:novo}}/",
+			wantPayload:  `{"task":7}`,
+			wantMissing:  "novo",
+		},
+		{
+			name:        "devolve o primeiro que falta",
+			payload:     `{"task":"{{ref:a}}","parent_log":"{{ref:b}}"}`,
+			wantPayload: `{"task":"{{ref:a}}","parent_log":"{{ref:b}}"}`,
+			wantMissing: "a",
+		},
+		{
+			name:         "sem placeholder",
+			endpoint:     "/tasks/",
+			payload:      `{"name":"x"}`,
+			wantEndpoint: "/tasks/",
+			wantPayload:  `{"name":"x"}`,
+		},
+	}
+
+	o := &Outbox{state: outboxState{Refs: map[string]int{"log1": 42, "task1": 7}}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev := outboxEvent{Endpoint: tt.endpoint}
+			if tt.payload != "" {
+				ev.Payload = json.RawMessage(tt.payload)
+			}
+
+			got, missing := o.resolveRefs(ev)
+			if got.Endpoint != tt.wantEndpoint {
+				t.Errorf("endpoint = %q, esperado %q", got.Endpoint, tt.wantEndpoint)
+			}
+			if string(got.Payload) != tt.wantPayload {
+				t.Errorf("payload = %s, esperado %s", got.Payload, tt.wantPayload)
+			}
+			if missing != tt.wantMissing {
+				t.Errorf("missing = %q, esperado %q", missing, tt.wantMissing)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package botapp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+)
+
+// Reporter é o que o robô usa para reportar execuções à dashboard.
+// O Client fala com a API de verdade; o Console só escreve no terminal (BotApp não configurado).
+// Nos testes, use um Client apontado para o botapptest.Server.
+type Reporter interface {
+	SetBot(name, description, version, department string) error
+	StartTask(ctx context.Context, name, description string) (*TaskContext, error)
+	RunTask(funcName, description string, taskFunc func() (any, error)) (any, error)
+	UploadArtifact(logID int, path, kind string) (*Artifact, error)
+	Ping(ctx context.Context) error
+}
+
+var (
+	_ Reporter = (*Client)(nil)
+	_ Reporter = (*Console)(nil)
+)
+
+// Console é o Reporter usado quando o BotApp não está configurado.
+// As tasks funcionam igual (Steps, Progress, Checkpoint), só que o histórico fica no terminal.
+type Console struct {
+	BotName string
+}
+
+// NewConsole cria o Reporter local
+func NewConsole() *Console {
+	return &Console{}
+}
+
+func (c *Console) SetBot(name, description, version, department string) error {
+	c.BotName = name
+	fmt.Printf("🤖 BotApp não configurado: '%s' %s rodando só com logs locais\n", name, version)
+	return nil
+}
+
+func (c *Console) StartTask(ctx context.Context, name, description string) (*TaskContext, error) {
+	return LocalTask(ctx, name), nil
+}
+
+func (c *Console) RunTask(funcName, description string, taskFunc func() (any, error)) (any, error) {
+	return LocalTask(context.Background(), funcName).Run(func(*TaskContext) (any, error) {
+		return taskFunc()
+	})
+}
+
+func (c *Console) UploadArtifact(logID int, path, kind string) (*Artifact, error) {
+	fmt.Printf("📎 Artefato (%s) não enviado, BotApp não configurado: %s\n", kind, filepath.Base(path))
+	return nil, nil
+}
+
+func (c *Console) Ping(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,61 @@
+package botapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// DefaultMaxResultBytes limita o result_data enviado para não inchar a dashboard
+const DefaultMaxResultBytes = 64 * 1024
+
+// Resulter permite que um tipo decida como aparece no result_data da dashboard.
+// Útil para resultados grandes (ex: DataFrame devolve contagem, headers e primeiras linhas).
+type Resulter interface {
+	ResultData() any
+}
+
+// buildResultData converte o retorno da task em JSON de verdade.
+// Tipos que não viram JSON (funções, canais...) caem no fmt "%v" de antes.
+// Acima de maxBytes o valor é trocado por um preview com marcador de truncamento.
+func buildResultData(result any, maxBytes int) map[string]any {
+	value := result
+	if r, ok := result.(Resulter); ok {
+		value = r.ResultData()
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprintf("%v", value))
+	}
+
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxResultBytes
+	}
+	if len(data) > maxBytes {
+		return map[string]any{
+			"truncated":  true,
+			"size_bytes": len(data),
+			"preview":    truncateUTF8(string(data), maxBytes),
+		}
+	}
+
+	return map[string]any{"return": json.RawMessage(data)}
+}
+
+// ResultSummary é o mesmo resumo do result_data, para quem quer guardá-lo em outro lugar
+// (ex: o histórico de execuções no banco). maxBytes <= 0 usa DefaultMaxResultBytes.
+func ResultSummary(result any, maxBytes int) map[string]any {
+	return buildResultData(result, maxBytes)
+}
+
+// truncateUTF8 corta a string em até n bytes sem quebrar um caractere multibyte no meio
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,313 @@
+package botapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/botlorien/go-rpa-template/pkg/metrics"
+)
+
+// progressMinInterval evita inundar a API quando Progress é chamado dentro de loops apertados
+const progressMinInterval = 2 * time.Second
+
+// TaskContext representa uma task em andamento na dashboard.
+// Steps criam TaskContexts filhos (com parent_log apontando para o pai), formando a árvore
+// que a dashboard usa para mostrar onde uma execução longa está e qual etapa falhou.
+//
+// Um TaskContext sem Client (ver LocalTask) funciona igual, só que sem enviar nada.
+type TaskContext struct {
+	client    *Client
+	ctx       context.Context
+	name      string
+	path      string // Caminho completo na árvore (ex: "Execução Geral/Download")
+	parent    *TaskContext
+	log       resourceRef
+	startTime time.Time
+
+	mu           sync.Mutex
+	lastProgress time.Time
+	finished     bool
+	stopBeat     chan struct{}
+	outputs      []output // Arquivos anexados ao log no fim (AddOutput)
+	declared     []string // Só na raiz: caminhos declarados na árvore toda (Outputs)
+
+	ctrl   *botControl             // Pausa vinda da dashboard (compartilhado com os Steps)
+	cancel context.CancelCauseFunc // Só na task raiz: para o watchBot no fim
+}
+
+// LocalTask cria um TaskContext que não reporta para a dashboard (BotApp não configurado).
+// Início e fim de cada task/step vão para o terminal (é o que o Console usa).
+func LocalTask(ctx context.Context, name string) *TaskContext {
+	fmt.Printf("▶️ [%s] iniciada\n", name)
+	return &TaskContext{ctx: ctx, name: name, path: name, startTime: time.Now()}
+}
+
+// StartTask registra a task e cria o log "started" na dashboard.
+// Quem chama é responsável por chamar Finish (ou usar Run).
+//
+// Durante a execução o estado do bot é consultado periodicamente: se for desativado
+// na dashboard, o Context() da task é cancelado; se for pausado, Checkpoint() espera.
+func (c *Client) StartTask(ctx context.Context, name, description string) (*TaskContext, error) {
+	if c.BotInstance == nil {
+		return nil, fmt.Errorf("bot não definido. Chame SetBot() antes")
+	}
+	// Confere o estado atual (o do SetBot pode estar velho numa API de longa duração)
+	active := c.BotInstance.IsActive
+	if bot, err := c.fetchBot(ctx); err == nil {
+		active = bot.IsActive
+	}
+	if !active {
+		return nil, fmt.Errorf("o bot '%s' está inativo", c.BotInstance.Name)
+	}
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	tc, err := c.start(runCtx, nil, name, description)
+	if err != nil {
+		cancel(nil)
+		return nil, err
+	}
+	tc.ctrl = newBotControl()
+	tc.cancel = cancel
+	go c.watchBot(runCtx, tc.ctrl, cancel)
+	return tc, nil
+}
+
+func (c *Client) start(ctx context.Context, parent *TaskContext, name, description string) (*TaskContext, error) {
+	// 1. Registra/Busca a Task na API
+	task, err := c.ensureTask(name, description)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao registrar task: %v", err)
+	}
+
+	tc := &TaskContext{
+		client:    c,
+		ctx:       ctx,
+		name:      name,
+		path:      name,
+		parent:    parent,
+		startTime: time.Now(),
+	}
+	if parent != nil {
+		tc.path = parent.path + "/" + name
+		tc.ctrl = parent.ctrl
+	}
+
+	// 2. Coleta dados do ambiente e cria o Log (STARTED)
+	logPayload := c.collectEnvInfo(ctx)
+	logPayload.TaskID = task.Value()
+	logPayload.Status = "started"
+	logPayload.StartTime = &tc.startTime
+	logPayload.StepPath = tc.path
+	logPayload.LastHeartbeat = &tc.startTime
+	// EndTime fica nil: com ponteiro, `omitempty` efetivamente omite do JSON.
+	if parent != nil && parent.log.Valid() {
+		logPayload.ParentLog = parent.log.Value()
+	}
+
+	// Com a API fora, o log vai para o outbox e recebe um ref local até ser reenviado
+	tc.log, err = c.create("/tasklog/", logPayload)
+	if err != nil {
+		fmt.Printf("⚠️ Erro ao criar log de início: %v\n", err)
+	} else {
+		tc.startHeartbeat()
+	}
+
+	return tc, nil
+}
+
+// Context é o contexto da execução (cancelado no shutdown)
+func (t *TaskContext) Context() context.Context {
+	return t.ctx
+}
+
+// Name é o nome da task na dashboard
+func (t *TaskContext) Name() string {
+	return t.name
+}
+
+// Path é o caminho completo da task na árvore de steps
+func (t *TaskContext) Path() string {
+	return t.path
+}
+
+// Step executa fn como uma sub-etapa desta task, com log próprio ligado ao pai
+func (t *TaskContext) Step(name string, fn func(*TaskContext) (any, error)) (any, error) {
+	var child *TaskContext
+	if t.client == nil {
+		child = &TaskContext{ctx: t.ctx, name: name, path: t.path + "/" + name, parent: t, startTime: time.Now(), ctrl: t.ctrl}
+		fmt.Printf("▶️ [%s] iniciada\n", child.path)
+	} else {
+		var err error
+		child, err = t.client.start(t.ctx, t, name, "Etapa de "+t.name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return child.Run(fn)
+}
+
+// Progress informa o avanço da task (ex: 30 de 120 páginas baixadas).
+// As chamadas são limitadas a uma a cada poucos segundos, exceto a que chega ao total.
+func (t *TaskContext) Progress(current, total int, message string) {
+	t.mu.Lock()
+	if t.finished || (current < total && time.Since(t.lastProgress) < progressMinInterval) {
+		t.mu.Unlock()
+		return
+	}
+	t.lastProgress = time.Now()
+	t.mu.Unlock()
+
+	percent := 0.0
+	if total > 0 {
+		percent = float64(current) * 100 / float64(total)
+	}
+
+	if t.client == nil || !t.log.Valid() {
+		fmt.Printf("📊 [%s] %d/%d (%.0f%%) %s\n", t.path, current, total, percent, message)
+		return
+	}
+
+	payload := map[string]interface{}{
+		"progress":         percent,
+		"progress_current": current,
+		"progress_total":   total,
+		"progress_message": message,
+	}
+	if _, err := t.client.send("PATCH", t.log.Path("/tasklog/"), payload); err != nil {
+		fmt.Printf("⚠️ Erro ao enviar progresso: %v\n", err)
+	}
+}
+
+// Run executa fn dentro desta task e a finaliza com o resultado.
+// Panics são registrados como falha e relançados.
+func (t *TaskContext) Run(fn func(*TaskContext) (any, error)) (any, error) {
+	var result any
+	var execErr error
+	var panicErr interface{}
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				panicErr = r // Captura panic (crash)
+			}
+		}()
+		result, execErr = fn(t)
+	}()
+
+	t.finish(result, execErr, panicErr)
+
+	// Retorna o resultado original para o código chamador
+	if panicErr != nil {
+		panic(panicErr) // Relança o pânico localmente
+	}
+	return result, execErr
+}
+
+// Finish fecha o log da task com o resultado (para quem usa StartTask sem Run)
+func (t *TaskContext) Finish(result any, err error) {
+	t.finish(result, err, nil)
+}
+
+func (t *TaskContext) finish(result any, execErr error, panicErr interface{}) {
+	t.mu.Lock()
+	if t.finished {
+		t.mu.Unlock()
+		return
+	}
+	t.finished = true
+	t.mu.Unlock()
+	t.stopHeartbeat()
+	if t.cancel != nil {
+		defer t.cancel(nil)
+	}
+
+	endTime := time.Now()
+
+	stepErr := execErr
+	if panicErr != nil {
+		stepErr = fmt.Errorf("panic: %v", panicErr)
+	}
+	metrics.ObserveStep(t.name, endTime.Sub(t.startTime), stepErr)
+
+	if t.client == nil {
+		t.printFinish(endTime.Sub(t.startTime), stepErr)
+		return
+	}
+	if !t.log.Valid() {
+		return
+	}
+
+	// Prepara o Payload de Finalização (PATCH)
+	finalPayload := map[string]interface{}{
+		"end_time": endTime,
+		"duration": formatDjangoDuration(endTime.Sub(t.startTime)),
+	}
+
+	if panicErr != nil {
+		// Se deu Panic (Crash)
+		finalPayload["status"] = "failed"
+		finalPayload["error_message"] = fmt.Sprintf("PANIC: %v\nStack: %s", panicErr, string(debug.Stack()))
+		finalPayload["exception_type"] = "Panic"
+	} else if errors.Is(execErr, context.Canceled) || errors.Is(execErr, context.DeadlineExceeded) {
+		// Execução cancelada no meio (shutdown da API / Ctrl+C)
+		finalPayload["status"] = "interrupted"
+		finalPayload["error_message"] = execErr.Error()
+		finalPayload["exception_type"] = "Interrupted"
+		if cause := context.Cause(t.ctx); errors.Is(cause, ErrBotDeactivated) {
+			// Operador desativou o bot: deixa claro que não foi shutdown nem erro do robô
+			if !errors.Is(execErr, ErrBotDeactivated) {
+				finalPayload["error_message"] = fmt.Sprintf("%v (%v)", cause, execErr)
+			}
+			finalPayload["exception_type"] = "Deactivated"
+		}
+	} else if execErr != nil {
+		// Se a função retornou erro
+		finalPayload["status"] = "failed"
+		finalPayload["error_message"] = execErr.Error()
+		finalPayload["exception_type"] = "Error"
+	} else {
+		// Sucesso
+		finalPayload["status"] = "completed"
+		finalPayload["progress"] = 100
+		finalPayload["result_data"] = buildResultData(result, t.client.Config.MaxResultBytes)
+	}
+
+	// Atualiza o Log (se o início ainda está no outbox, o fim entra na fila atrás dele)
+	if _, err := t.client.send("PATCH", t.log.Path("/tasklog/"), finalPayload); err != nil {
+		fmt.Printf("⚠️ Erro ao fechar log: %v\n", err)
+	}
+
+	t.uploadOutputs()
+}
+
+// printFinish é o "log de fim" das tasks locais
+func (t *TaskContext) printFinish(d time.Duration, err error) {
+	switch {
+	case err == nil:
+		fmt.Printf("✅ [%s] concluída em %s\n", t.path, d.Round(time.Millisecond))
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		fmt.Printf("⏹️ [%s] interrompida após %s: %v\n", t.path, d.Round(time.Millisecond), err)
+	default:
+		fmt.Printf("❌ [%s] falhou após %s: %v\n", t.path, d.Round(time.Millisecond), err)
+	}
+}
+
+// RunTask é o wrapper (o "decorator") que envolve sua função
+// funcName: Nome da tarefa na dashboard
+// description: Descrição da tarefa
+// taskFunc: A função que contém sua lógica
+//
+// Para etapas aninhadas e progresso, use StartTask + Step/Progress.
+func (c *Client) RunTask(funcName, description string, taskFunc func() (any, error)) (any, error) {
+	tc, err := c.StartTask(context.Background(), funcName, description)
+	if err != nil {
+		return nil, err
+	}
+	return tc.Run(func(*TaskContext) (any, error) {
+		return taskFunc()
+	})
+}
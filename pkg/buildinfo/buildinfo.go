@@ -0,0 +1,95 @@
+// Package buildinfo descobre o que está de fato rodando: versão, commit e se o build tinha
+// alterações não commitadas. Usado na versão do bot na dashboard e nos logs do BotApp.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// Preenchidos no build quando o binário é compilado sem o .git (ex: Docker):
+//
+//	go build -ldflags "-X github.com/botlorien/go-rpa-template/pkg/buildinfo.Version=1.4.0 \
+//	  -X github.com/botlorien/go-rpa-template/pkg/buildinfo.Commit=$(git rev-parse HEAD)" ./cmd/api
+var (
+	Version string
+	Commit  string
+)
+
+// Info é a identificação do build
+type Info struct {
+	Version   string `json:"version"`    // ldflags, versão do módulo (tag) ou "dev"
+	Commit    string `json:"commit"`     // Revisão do VCS (hash completo)
+	Dirty     bool   `json:"dirty"`      // Build feito com alterações não commitadas
+	BuildTime string `json:"build_time"` // Data do commit (vcs.time)
+	GoVersion string `json:"go_version"`
+}
+
+var (
+	once sync.Once
+	info Info
+)
+
+// Get lê as informações do build (uma vez só; o resultado não muda durante o processo)
+func Get() Info {
+	once.Do(func() {
+		info = Info{Version: Version, Commit: Commit, GoVersion: runtime.Version()}
+
+		bi, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.modified":
+				info.Dirty = s.Value == "true"
+			case "vcs.time":
+				info.BuildTime = s.Value
+			}
+		}
+	})
+
+	out := info
+	if out.Version == "" {
+		out.Version = "dev"
+	}
+	return out
+}
+
+// ShortCommit são os 7 primeiros caracteres do commit (como o git mostra)
+func (i Info) ShortCommit() string {
+	if len(i.Commit) > 7 {
+		return i.Commit[:7]
+	}
+	return i.Commit
+}
+
+// FullVersion junta versão, commit e dirty no formato de build metadata do semver.
+// Ex: "v1.4.0+a1b2c3d", "dev+a1b2c3d.dirty". Pseudo-versões do Go já trazem o commit.
+func (i Info) FullVersion() string {
+	version := i.Version
+	var meta []string
+	if short := i.ShortCommit(); short != "" && !strings.Contains(version, short) {
+		meta = append(meta, short)
+	}
+	if i.Dirty && !strings.Contains(version, "dirty") {
+		meta = append(meta, "dirty")
+	}
+	if len(meta) == 0 {
+		return version
+	}
+	sep := "+"
+	if strings.Contains(version, "+") {
+		sep = "."
+	}
+	return version + sep + strings.Join(meta, ".")
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// clickhouseSettings vão no DSN do ClickHouse, a não ser que ele já traga o parâmetro.
+// mutations_sync=2 faz o ALTER TABLE ... DELETE/UPDATE (o DELETE/UPDATE do GORM lá) esperar
+// a mutation terminar em todas as réplicas: sem isso o upsert/rollback leria a linha antiga.
+var clickhouseSettings = []struct{ name, value string }{
+	{"mutations_sync", "2"},
+}
+
+// clickhouseDSN acrescenta os settings padrão no DSN (parâmetro desconhecido vira setting no clickhouse-go)
+func clickhouseDSN(dsn string) string {
+	var params []string
+	for _, s := range clickhouseSettings {
+		if strings.Contains(dsn, s.name+"=") {
+			continue
+		}
+		params = append(params, s.name+"="+s.value)
+	}
+	if len(params) == 0 {
+		return dsn
+	}
+
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + strings.Join(params, "&")
+}
+
+// epochID é o marco zero dos IDs gerados (cabe ~69 anos de milissegundos em 41 bits)
+var epochID = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// idGenerator gera IDs crescentes sem o banco (estilo snowflake):
+// 41 bits de milissegundos desde epochID | 10 bits do processo | 12 bits de sequência.
+// O ClickHouse não tem autoincremento; sem isso todo gorm.Model.ID sairia 0
+// e a paginação por cursor e o rollback (ambos pela chave primária) quebrariam.
+type idGenerator struct {
+	mu     sync.Mutex
+	node   uint64 // Sorteado na subida: dois processos gravando juntos não geram o mesmo ID
+	lastMs int64
+	seq    uint64
+}
+
+var ids = newIDGenerator()
+
+func newIDGenerator() *idGenerator {
+	var b [2]byte
+	_, _ = rand.Read(b[:])
+	return &idGenerator{node: uint64(binary.BigEndian.Uint16(b[:])) & 0x3FF}
+}
+
+func (g *idGenerator) next() uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := time.Since(epochID).Milliseconds()
+	if ms < g.lastMs {
+		ms = g.lastMs // Relógio voltou (NTP): segura no último ms em vez de repetir ID
+	}
+	if ms == g.lastMs {
+		g.seq = (g.seq + 1) & 0xFFF
+		if g.seq == 0 {
+			// 4096 IDs no mesmo ms: espera o próximo
+			for ms <= g.lastMs {
+				time.Sleep(100 * time.Microsecond)
+				ms = max(time.Since(epochID).Milliseconds(), ms)
+			}
+		}
+	} else {
+		g.seq = 0
+	}
+	g.lastMs = ms
+	return uint64(ms)<<22 | g.node<<12 | g.seq
+}
+
+// NewID gera uma chave primária para banco sem autoincremento (ClickHouse).
+// Também usado pela carga em massa, que não passa pelos callbacks.
+func NewID() uint64 {
+	return ids.next()
+}
+
+// registerClickHouseIDs instala o callback que preenche a chave autoincremento zerada
+// antes do INSERT (Create, CreateInBatches, Upsert)
+func registerClickHouseIDs(db *gorm.DB) error {
+	return db.Callback().Create().Before("gorm:create").Register("clickhouse:ids", stampIDs)
+}
+
+func stampIDs(db *gorm.DB) {
+	if db.Statement.Schema == nil {
+		return
+	}
+	pk := db.Statement.Schema.PrioritizedPrimaryField
+	if pk == nil || !pk.AutoIncrement {
+		return
+	}
+
+	ctx := db.Statement.Context
+	stamp := func(rv reflect.Value) {
+		if _, zero := pk.ValueOf(ctx, rv); zero {
+			if err := pk.Set(ctx, rv, NewID()); err != nil {
+				db.AddError(fmt.Errorf("falha ao gerar ID de %s: %w", db.Statement.Schema.Table, err))
+			}
+		}
+	}
+
+	switch rv := reflect.Indirect(db.Statement.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if elem := reflect.Indirect(rv.Index(i)); elem.Kind() == reflect.Struct {
+				stamp(elem)
+			}
+		}
+	case reflect.Struct:
+		stamp(rv)
+	}
+}
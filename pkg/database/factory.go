@@ -2,38 +2,177 @@ package database
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"gorm.io/driver/clickhouse"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlserver"
 	"github.com/glebarez/sqlite"
+	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-// NewConnection cria uma conexão baseada no driver escolhido
+// maxConnectBackoff limita a espera entre tentativas de conexão na subida
+const maxConnectBackoff = 30 * time.Second
+
+// Options ajusta pool, retry na subida, timeout de statement e log.
+// Campo zerado = padrão do database/sql (pool sem limite, conexão sem prazo).
+type Options struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration // Recicla conexões (LB/proxy do banco derruba as velhas)
+	ConnMaxIdleTime time.Duration
+
+	ConnectRetries int           // Tentativas extras se o banco não responder na subida
+	ConnectBackoff time.Duration // Espera antes da 1ª nova tentativa; dobra a cada falha (máx 30s)
+
+	StatementTimeout time.Duration // Prazo de cada Create/Find/Update/Delete sem deadline no ctx
+
+	Logger LoggerConfig
+}
+
+// NewConnection cria uma conexão baseada no driver escolhido (opções padrão, log via zerolog)
 func NewConnection(driver, dsn string) (*gorm.DB, error) {
-	var dialect gorm.Dialector
+	return Open(driver, dsn, Options{})
+}
+
+// Open cria a conexão com as opções de pool/retry/timeout/log.
+// Se o banco ainda não estiver de pé (comum no docker-compose), tenta de novo com backoff.
+func Open(driver, dsn string, opts Options) (*gorm.DB, error) {
+	// Driver/DSN errados não se resolvem esperando: falha antes do loop de retry
+	if err := ValidateDSN(driver, dsn); err != nil {
+		return nil, err
+	}
+
+	// Configuração GORM global
+	config := &gorm.Config{
+		Logger: NewLogger(opts.Logger),
+	}
+
+	backoff := opts.ConnectBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	var db *gorm.DB
+	for attempt := 0; ; attempt++ {
+		// Dialector novo a cada tentativa: alguns guardam a conexão da tentativa anterior
+		dialect, _ := dialectorFor(driver, dsn)
+		var err error
+		db, err = gorm.Open(dialect, config)
+		if err == nil {
+			break
+		}
+		closeQuietly(db)
+		if attempt >= opts.ConnectRetries {
+			return nil, fmt.Errorf("banco indisponível após %d tentativa(s): %w", attempt+1, err)
+		}
+		log.Warn().Err(err).Str("driver", driver).Int("tentativa", attempt+1).Dur("proxima_em", backoff).
+			Msg("Banco indisponível, tentando de novo")
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if opts.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+	if opts.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+	if opts.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	}
+	if opts.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+	}
 
+	if driver == "clickhouse" {
+		if err := registerClickHouseIDs(db); err != nil {
+			sqlDB.Close()
+			return nil, fmt.Errorf("falha ao configurar IDs do clickhouse: %w", err)
+		}
+	}
+
+	if opts.StatementTimeout > 0 {
+		if err := registerStatementTimeout(db, opts.StatementTimeout); err != nil {
+			sqlDB.Close()
+			return nil, fmt.Errorf("falha ao configurar timeout de statement: %w", err)
+		}
+	}
+
+	return db, nil
+}
+
+func dialectorFor(driver, dsn string) (gorm.Dialector, error) {
 	switch driver {
 	case "postgres":
-		dialect = postgres.Open(dsn)
+		return postgres.Open(dsn), nil
 	case "mysql":
-		dialect = mysql.Open(dsn)
+		return mysql.Open(dsn), nil
 	case "sqlite":
-		dialect = sqlite.Open(dsn)
+		return sqlite.Open(sqliteDSN(dsn)), nil
+	case "sqlserver":
+		return sqlserver.Open(dsn), nil
+	case "clickhouse":
+		return clickhouse.Open(clickhouseDSN(dsn)), nil
 	default:
 		return nil, fmt.Errorf("driver de banco de dados não suportado: %s", driver)
 	}
+}
 
-	// Configuração GORM global
-	config := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+// sqlitePragmas são aplicados em toda conexão do sqlite, a não ser que o DSN já traga o pragma:
+// WAL deixa ler enquanto outra conexão escreve, busy_timeout espera o lock em vez de falhar
+// na hora com "database is locked", e foreign_keys vem desligado por padrão no sqlite.
+var sqlitePragmas = []struct{ name, value string }{
+	{"journal_mode", "WAL"},
+	{"busy_timeout", "5000"},
+	{"foreign_keys", "1"},
+}
+
+// sqliteDSN acrescenta os pragmas padrão no DSN (?_pragma=nome(valor), formato do driver modernc)
+func sqliteDSN(dsn string) string {
+	var params []string
+	for _, p := range sqlitePragmas {
+		if strings.Contains(dsn, p.name) {
+			continue
+		}
+		// Banco em memória não tem arquivo de journal: WAL não se aplica
+		if p.name == "journal_mode" && strings.Contains(dsn, ":memory:") {
+			continue
+		}
+		params = append(params, fmt.Sprintf("_pragma=%s(%s)", p.name, p.value))
+	}
+	if len(params) == 0 {
+		return dsn
 	}
 
-	db, err := gorm.Open(dialect, config)
-	if err != nil {
-		return nil, err
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
 	}
+	return dsn + sep + strings.Join(params, "&")
+}
 
-	return db, nil
-}
\ No newline at end of file
+func closeQuietly(db *gorm.DB) {
+	if db == nil {
+		return
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
+	}
+}
+
+// Close fecha o pool de conexões (usado no shutdown)
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
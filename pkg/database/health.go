@@ -0,0 +1,16 @@
+package database
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Ping verifica se o banco está respondendo (usado no readiness check)
+func Ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
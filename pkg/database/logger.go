@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// DefaultSlowThreshold é a partir de quanto uma query vira warning de "query lenta"
+const DefaultSlowThreshold = 500 * time.Millisecond
+
+// LoggerConfig controla o que o GORM manda para o zerolog
+type LoggerConfig struct {
+	Level         gormlogger.LogLevel // Silent, Error, Warn (padrão) ou Info (todo SQL, em nível debug)
+	SlowThreshold time.Duration       // 0 = DefaultSlowThreshold
+	LogParams     bool                // false = SQL sem os valores (senhas/CPFs não vão para o log)
+}
+
+// ParseLogLevel traduz o DB_LOG_LEVEL (silent, error, warn, info). Vazio ou inválido = warn.
+func ParseLogLevel(level string) gormlogger.LogLevel {
+	switch strings.ToLower(level) {
+	case "silent":
+		return gormlogger.Silent
+	case "error":
+		return gormlogger.Error
+	case "info", "debug":
+		return gormlogger.Info
+	default:
+		return gormlogger.Warn
+	}
+}
+
+// zerologLogger é o logger do GORM que escreve no zerolog (mesmo formato/saída do resto da aplicação).
+// Implementa gorm.ParamsFilter: sem LogParams, o GORM monta o SQL do log sem os valores.
+type zerologLogger struct {
+	cfg LoggerConfig
+}
+
+// NewLogger cria o adaptador GORM -> zerolog
+func NewLogger(cfg LoggerConfig) gormlogger.Interface {
+	if cfg.Level == 0 {
+		cfg.Level = gormlogger.Warn
+	}
+	if cfg.SlowThreshold <= 0 {
+		cfg.SlowThreshold = DefaultSlowThreshold
+	}
+	return &zerologLogger{cfg: cfg}
+}
+
+func (l *zerologLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.cfg.Level = level
+	return &clone
+}
+
+func (l *zerologLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.cfg.Level >= gormlogger.Info {
+		log.Info().Str("component", "gorm").Msg(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *zerologLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.cfg.Level >= gormlogger.Warn {
+		log.Warn().Str("component", "gorm").Msg(fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *zerologLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.cfg.Level >= gormlogger.Error {
+		log.Error().Str("component", "gorm").Msg(fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace é chamado pelo GORM no fim de cada comando: erro, query lenta ou (no nível Info) tudo
+func (l *zerologLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.cfg.Level <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+
+	switch {
+	case err != nil && l.cfg.Level >= gormlogger.Error && !errors.Is(err, gormlogger.ErrRecordNotFound):
+		sql, rows := fc()
+		log.Error().Err(err).Dur("elapsed", elapsed).Int64("rows", rows).Str("sql", sql).Msg("Erro no SQL")
+	case elapsed > l.cfg.SlowThreshold && l.cfg.Level >= gormlogger.Warn:
+		sql, rows := fc()
+		log.Warn().Dur("elapsed", elapsed).Dur("threshold", l.cfg.SlowThreshold).Int64("rows", rows).Str("sql", sql).Msg("Query lenta")
+	case l.cfg.Level >= gormlogger.Info:
+		sql, rows := fc()
+		log.Debug().Dur("elapsed", elapsed).Int64("rows", rows).Str("sql", sql).Msg("SQL")
+	}
+}
+
+// ParamsFilter decide se os valores entram no SQL do log (implementa gorm.ParamsFilter)
+func (l *zerologLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.cfg.LogParams {
+		return sql, params
+	}
+	return sql, nil
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const statementTimeoutKey = "database:statement_timeout"
+
+// statementTimeout guarda o que o callback "after" precisa desfazer
+type statementTimeout struct {
+	parent context.Context
+	cancel context.CancelFunc
+}
+
+// registerStatementTimeout põe um prazo em cada Create/Find/Update/Delete sem prazo próprio.
+// Quem chama com um ctx que já tem deadline (ex: o readiness check) manda no próprio prazo.
+//
+// Row/Rows/Exec ficam de fora: o Rows devolve um cursor que seria cancelado antes da leitura,
+// e o Exec é usado pelas migrações, que podem demorar mais que uma query normal.
+func registerStatementTimeout(db *gorm.DB, timeout time.Duration) error {
+	before := func(tx *gorm.DB) {
+		parent := tx.Statement.Context
+		if parent == nil {
+			parent = context.Background()
+		}
+		if _, ok := parent.Deadline(); ok {
+			return
+		}
+		ctx, cancel := context.WithTimeout(parent, timeout)
+		tx.Statement.Context = ctx
+		tx.InstanceSet(statementTimeoutKey, statementTimeout{parent: parent, cancel: cancel})
+	}
+	after := func(tx *gorm.DB) {
+		v, _ := tx.InstanceGet(statementTimeoutKey)
+		if st, ok := v.(statementTimeout); ok {
+			st.cancel()
+			// O mesmo *gorm.DB pode ser reaproveitado (ex: Count e depois Find): volta o ctx original
+			tx.Statement.Context = st.parent
+			tx.InstanceSet(statementTimeoutKey, nil)
+		}
+	}
+
+	cb := db.Callback()
+	if err := cb.Create().Before("*").Register("database:timeout_before_create", before); err != nil {
+		return err
+	}
+	if err := cb.Create().After("*").Register("database:timeout_after_create", after); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("*").Register("database:timeout_before_query", before); err != nil {
+		return err
+	}
+	if err := cb.Query().After("*").Register("database:timeout_after_query", after); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("*").Register("database:timeout_before_update", before); err != nil {
+		return err
+	}
+	if err := cb.Update().After("*").Register("database:timeout_after_update", after); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("*").Register("database:timeout_before_delete", before); err != nil {
+		return err
+	}
+	return cb.Delete().After("*").Register("database:timeout_after_delete", after)
+}
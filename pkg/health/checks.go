@@ -0,0 +1,23 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/botlorien/go-rpa-template/pkg/utils"
+)
+
+// DiskSpace cria um Check que falha quando o volume do path tem menos de minFreeMB livres
+func DiskSpace(path string, minFreeMB uint64) Check {
+	return func(ctx context.Context) error {
+		free, err := utils.FreeDiskSpace(path)
+		if err != nil {
+			return err
+		}
+		freeMB := free / (1024 * 1024)
+		if freeMB < minFreeMB {
+			return fmt.Errorf("espaço livre insuficiente em %s: %d MB (mínimo %d MB)", path, freeMB, minFreeMB)
+		}
+		return nil
+	}
+}
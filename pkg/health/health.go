@@ -0,0 +1,155 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded"
+)
+
+// Check verifica um componente. Retorna erro se ele estiver indisponível.
+type Check func(ctx context.Context) error
+
+// ComponentStatus é o resultado de um Check individual
+type ComponentStatus struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Critical  bool    `json:"critical"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report é o resultado consolidado de todos os Checks
+type Report struct {
+	Status     string                     `json:"status"`
+	CheckedAt  time.Time                  `json:"checked_at"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+// Healthy indica se a instância pode receber tráfego (nenhum componente crítico caiu)
+func (r Report) Healthy() bool {
+	return r.Status != StatusDown
+}
+
+type component struct {
+	name     string
+	check    Check
+	critical bool
+}
+
+// Checker agrega as verificações de prontidão (readiness) da aplicação
+type Checker struct {
+	Timeout time.Duration
+
+	mu         sync.RWMutex
+	components []component
+}
+
+// NewChecker cria um Checker. Cada Check roda com o timeout informado.
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &Checker{Timeout: timeout}
+}
+
+// Register adiciona um componente crítico: se falhar, a instância fica "down" (503)
+func (c *Checker) Register(name string, check Check) {
+	c.add(component{name: name, check: check, critical: true})
+}
+
+// RegisterOptional adiciona um componente não crítico: se falhar, a instância fica "degraded"
+// mas continua recebendo tráfego (ex: dashboard do BotApp fora do ar).
+func (c *Checker) RegisterOptional(name string, check Check) {
+	c.add(component{name: name, check: check, critical: false})
+}
+
+func (c *Checker) add(comp component) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.components = append(c.components, comp)
+}
+
+// Run executa todos os Checks em paralelo e consolida o resultado
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	components := make([]component, len(c.components))
+	copy(components, c.components)
+	c.mu.RUnlock()
+
+	report := Report{
+		Status:     StatusUp,
+		CheckedAt:  time.Now(),
+		Components: make(map[string]ComponentStatus, len(components)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, comp := range components {
+		wg.Add(1)
+		go func(comp component) {
+			defer wg.Done()
+			status := c.runOne(ctx, comp)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[comp.name] = status
+			if status.Status == StatusDown {
+				if comp.critical {
+					report.Status = StatusDown
+				} else if report.Status == StatusUp {
+					report.Status = StatusDegraded
+				}
+			}
+		}(comp)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (c *Checker) runOne(ctx context.Context, comp component) ComponentStatus {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			// Um Check que dá panic não pode derrubar o endpoint de health
+			if r := recover(); r != nil {
+				errCh <- panicError{r}
+			}
+		}()
+		errCh <- comp.check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	status := ComponentStatus{
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Critical:  comp.critical,
+	}
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
+
+type panicError struct{ v any }
+
+func (p panicError) Error() string {
+	return fmt.Sprintf("panic no check: %v", p.v)
+}
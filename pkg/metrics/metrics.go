@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "rpa"
+
+var (
+	// RunsTotal conta execuções completas do robô (Service.Execute) por status
+	RunsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "runs_total",
+		Help:      "Execuções do robô por status (completed, failed).",
+	}, []string{"status"})
+
+	// RunDuration mede a duração das execuções completas. Buckets pensados para RPA (segundos a 1h).
+	RunDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "run_duration_seconds",
+		Help:      "Duração das execuções do robô.",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600},
+	}, []string{"status"})
+
+	// StepDuration mede cada task executada via botapp.Client.RunTask
+	StepDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "step_duration_seconds",
+		Help:      "Duração de cada task/step do robô.",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"task", "status"})
+
+	// TargetHTTPRequests conta as requisições feitas ao sistema alvo
+	TargetHTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "target_http_requests_total",
+		Help:      "Requisições HTTP ao sistema alvo por método e status code.",
+	}, []string{"code", "method"})
+
+	// DownloadBytes soma os bytes baixados do sistema alvo
+	DownloadBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "download_bytes_total",
+		Help:      "Bytes baixados do sistema alvo.",
+	})
+
+	// RowsProcessed conta as linhas lidas pelo processor (CSV/XLSX)
+	RowsProcessed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rows_processed_total",
+		Help:      "Linhas carregadas pelo processor.",
+	})
+
+	// RowsSaved conta as linhas gravadas no banco por tabela
+	RowsSaved = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rows_saved_total",
+		Help:      "Linhas gravadas no banco por tabela.",
+	}, []string{"table"})
+
+	// Purged conta o que a rotina de retenção removeu (tabela do banco ou pasta de arquivos)
+	Purged = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "purged_total",
+		Help:      "Linhas e arquivos removidos pela política de retenção.",
+	}, []string{"target"})
+)
+
+// ObserveRun registra uma execução completa do robô
+func ObserveRun(duration time.Duration, err error) {
+	status := statusOf(err)
+	RunsTotal.WithLabelValues(status).Inc()
+	RunDuration.WithLabelValues(status).Observe(duration.Seconds())
+}
+
+// ObserveStep registra uma task/step do robô
+func ObserveStep(task string, duration time.Duration, err error) {
+	StepDuration.WithLabelValues(task, statusOf(err)).Observe(duration.Seconds())
+}
+
+// RegisterGaugeFunc expõe um valor lido sob demanda no scrape (ex: profundidade da fila).
+// Assim o pacote de métricas não precisa conhecer quem fornece o valor.
+func RegisterGaugeFunc(name, help string, fn func() float64) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      name,
+		Help:      help,
+	}, fn)
+}
+
+// InstrumentTransport envolve um RoundTripper contando as requisições por status code
+func InstrumentTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return promhttp.InstrumentRoundTripperCounter(TargetHTTPRequests, next)
+}
+
+// Handler é o endpoint /metrics no formato texto do Prometheus
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+func statusOf(err error) string {
+	if err != nil {
+		return "failed"
+	}
+	return "completed"
+}
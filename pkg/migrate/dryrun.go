@@ -0,0 +1,86 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// dryRunSession é a sessão do dry-run: as consultas (o Migrator checando se a tabela/coluna
+// existe) vão para o banco de verdade; os comandos que alterariam algo são só impressos.
+//
+// Não usa o DryRun do GORM: nele o Migrator imprime o DDL por conta própria no stdout
+// e o SQL de tx.Exec passa só pelo logger, sem jeito estável de separar um do outro.
+func dryRunSession(ctx context.Context, db *gorm.DB, out io.Writer) *gorm.DB {
+	tx := db.Session(&gorm.Session{Logger: logger.Discard, Context: ctx})
+	tx.Statement.ConnPool = &dryRunPool{ConnPool: tx.Statement.ConnPool, explain: db.Dialector.Explain, out: out}
+	return tx
+}
+
+// dryRunPool embrulha a conexão: Exec imprime o SQL em vez de executar.
+// Também é um TxCommitter, então um Transaction dentro da migração vira savepoint nele mesmo.
+type dryRunPool struct {
+	gorm.ConnPool
+	explain func(sql string, vars ...interface{}) string
+	out     io.Writer
+}
+
+func (p *dryRunPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	p.print(query, args)
+	return driver.RowsAffected(0), nil
+}
+
+// QueryContext só deixa passar leitura. INSERT ... RETURNING (postgres) também vem por aqui:
+// esse é impresso e falha, porque não há linha de verdade para devolver.
+func (p *dryRunPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if !isRead(query) {
+		p.print(query, args)
+		return nil, fmt.Errorf("dry-run: comando com retorno não pode ser simulado: %s", firstWord(query))
+	}
+	return p.ConnPool.QueryContext(ctx, query, args...)
+}
+
+func (p *dryRunPool) Commit() error   { return nil }
+func (p *dryRunPool) Rollback() error { return nil }
+
+func (p *dryRunPool) print(query string, args []interface{}) {
+	sql := strings.TrimSuffix(strings.TrimSpace(p.explain(query, args...)), ";")
+	if sql == "" || isSavepoint(sql) {
+		return
+	}
+	fmt.Fprintf(p.out, "%s;\n", sql)
+}
+
+// isSavepoint diz se o comando é do Transaction aninhado (savepoint), não da migração
+func isSavepoint(sql string) bool {
+	upper := strings.ToUpper(sql)
+	for _, prefix := range []string{"SAVEPOINT ", "RELEASE SAVEPOINT ", "ROLLBACK TO SAVEPOINT ", "SAVE TRANSACTION ", "ROLLBACK TRANSACTION "} {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRead diz se o comando só lê (o Migrator consultando o catálogo)
+func isRead(query string) bool {
+	switch strings.ToUpper(firstWord(query)) {
+	case "SELECT", "PRAGMA", "SHOW", "DESCRIBE", "DESC", "EXPLAIN", "WITH":
+		return true
+	}
+	return false
+}
+
+func firstWord(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
@@ -0,0 +1,297 @@
+// Package migrate aplica migrações versionadas (Go ou SQL) e registra o que já rodou
+// na tabela schema_migrations. Um lock no banco (advisory lock no postgres, GET_LOCK no
+// mysql) impede que duas instâncias subindo juntas apliquem a mesma migração.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultLockTimeout é quanto esperamos outra instância terminar de migrar
+const DefaultLockTimeout = time.Minute
+
+// lockID identifica o lock das migrações (pg_advisory_lock / GET_LOCK). Qualquer constante serve,
+// desde que seja a mesma em todas as instâncias.
+const (
+	lockID   int64 = 727465726174 // "rpa" + "migrate", só precisa ser fixo
+	lockName       = "schema_migrations"
+)
+
+// Migration é um passo versionado do schema. Versões são aplicadas em ordem crescente
+// e nunca devem ser renumeradas depois de publicadas.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error // Opcional: sem Down, a migração não pode ser revertida
+}
+
+// SQL cria as funções de uma migração em SQL puro (cada string pode ter vários comandos se o driver aceitar)
+func SQL(up, down string) (func(tx *gorm.DB) error, func(tx *gorm.DB) error) {
+	upFn := func(tx *gorm.DB) error { return tx.Exec(up).Error }
+	if down == "" {
+		return upFn, nil
+	}
+	return upFn, func(tx *gorm.DB) error { return tx.Exec(down).Error }
+}
+
+// Status é a situação de uma migração no banco
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+	Unknown   bool // Aplicada no banco mas ausente no código (binário antigo?)
+}
+
+// schemaMigration é a linha da tabela de controle
+type schemaMigration struct {
+	Version   int64  `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"size:255"`
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string { return "schema_migrations" }
+
+// Migrator aplica as migrações num banco
+type Migrator struct {
+	DB          *gorm.DB
+	Migrations  []Migration
+	LockTimeout time.Duration
+	DryRun      bool      // Só imprime o SQL que seria executado
+	Out         io.Writer // Destino do SQL no dry-run e das mensagens (padrão: stdout)
+}
+
+// New valida a lista (versões únicas, Up obrigatório) e a ordena por versão
+func New(db *gorm.DB, migrations []Migration) (*Migrator, error) {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	for i, m := range sorted {
+		if m.Version <= 0 {
+			return nil, fmt.Errorf("migração %q com versão inválida: %d", m.Name, m.Version)
+		}
+		if m.Up == nil {
+			return nil, fmt.Errorf("migração %d (%s) sem Up", m.Version, m.Name)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("versão de migração duplicada: %d (%s e %s)", m.Version, sorted[i-1].Name, m.Name)
+		}
+	}
+
+	return &Migrator{DB: db, Migrations: sorted, LockTimeout: DefaultLockTimeout, Out: os.Stdout}, nil
+}
+
+// Up aplica todas as migrações pendentes, em ordem. Retorna as que foram aplicadas.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, mig := range m.Migrations {
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		if err := m.run(ctx, mig, mig.Up, true); err != nil {
+			return done, fmt.Errorf("migração %d (%s) falhou: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Down reverte as últimas `steps` migrações aplicadas, da mais nova para a mais antiga
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, errors.New("número de passos do down deve ser maior que zero")
+	}
+
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(m.Migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		mig := m.Migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		if mig.Down == nil {
+			return done, fmt.Errorf("migração %d (%s) não tem Down", mig.Version, mig.Name)
+		}
+		if err := m.run(ctx, mig, mig.Down, false); err != nil {
+			return done, fmt.Errorf("down da migração %d (%s) falhou: %w", mig.Version, mig.Name, err)
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+// Status lista as migrações do código e as aplicadas no banco
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Status
+	for _, mig := range m.Migrations {
+		st := Status{Version: mig.Version, Name: mig.Name}
+		if row, ok := applied[mig.Version]; ok {
+			at := row.AppliedAt
+			st.Applied, st.AppliedAt = true, &at
+			delete(applied, mig.Version)
+		}
+		out = append(out, st)
+	}
+	for _, row := range applied {
+		at := row.AppliedAt
+		out = append(out, Status{Version: row.Version, Name: row.Name, Applied: true, AppliedAt: &at, Unknown: true})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// run executa um passo numa transação junto com o registro em schema_migrations
+// (no mysql DDL faz commit implícito, então lá a atomicidade é só do registro)
+func (m *Migrator) run(ctx context.Context, mig Migration, step func(*gorm.DB) error, up bool) error {
+	direction := "up"
+	if !up {
+		direction = "down"
+	}
+
+	if m.DryRun {
+		fmt.Fprintf(m.out(), "-- %d %s (%s)\n", mig.Version, mig.Name, direction)
+		return step(dryRunSession(ctx, m.DB, m.out()))
+	}
+
+	start := time.Now()
+	err := m.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := step(tx); err != nil {
+			return err
+		}
+		if up {
+			return tx.Create(&schemaMigration{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now()}).Error
+		}
+		return tx.Delete(&schemaMigration{}, mig.Version).Error
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(m.out(), "✅ Migração %d %s (%s) em %s\n", mig.Version, mig.Name, direction, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// applied lê schema_migrations (criando a tabela se preciso; no dry-run só lê se ela existir)
+func (m *Migrator) applied(ctx context.Context) (map[int64]schemaMigration, error) {
+	db := m.DB.WithContext(ctx)
+	out := map[int64]schemaMigration{}
+
+	if !db.Migrator().HasTable(&schemaMigration{}) {
+		if m.DryRun {
+			return out, nil
+		}
+		if err := db.Migrator().CreateTable(&schemaMigration{}); err != nil {
+			return nil, fmt.Errorf("falha ao criar schema_migrations: %w", err)
+		}
+	}
+
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("falha ao ler schema_migrations: %w", err)
+	}
+	for _, row := range rows {
+		out[row.Version] = row
+	}
+	return out, nil
+}
+
+// lock pega o lock de migração do banco. O lock é de sessão, então fica preso a uma conexão
+// dedicada do pool até o unlock. Bancos sem suporte (sqlite, clickhouse) seguem sem lock.
+func (m *Migrator) lock(ctx context.Context) (func(), error) {
+	noop := func() {}
+	if m.DryRun {
+		return noop, nil
+	}
+
+	var lockSQL, unlockSQL string
+	var args []any
+	switch m.DB.Dialector.Name() {
+	case "postgres":
+		lockSQL, unlockSQL, args = "SELECT pg_advisory_lock($1)", "SELECT pg_advisory_unlock($1)", []any{lockID}
+	case "mysql":
+		lockSQL, unlockSQL, args = "SELECT GET_LOCK(?, ?)", "SELECT RELEASE_LOCK(?)", []any{lockName, int(m.lockTimeout().Seconds())}
+	case "sqlserver":
+		// sp_getapplock devolve >= 0 quando pegou o lock; o CASE traduz para o 1 do GET_LOCK
+		lockSQL = "DECLARE @r int; EXEC @r = sp_getapplock @Resource = @p1, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = @p2; SELECT CASE WHEN @r >= 0 THEN 1 ELSE 0 END"
+		unlockSQL = "EXEC sp_releaseapplock @Resource = @p1, @LockOwner = 'Session'"
+		args = []any{lockName, int(m.lockTimeout().Milliseconds())}
+	default:
+		return noop, nil
+	}
+
+	sqlDB, err := m.DB.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lockCtx, cancel := context.WithTimeout(ctx, m.lockTimeout())
+	defer cancel()
+	var got sql.NullInt64
+	if err := conn.QueryRowContext(lockCtx, lockSQL, args...).Scan(&got); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("não foi possível obter o lock de migração (outra instância migrando?): %w", err)
+	}
+	// pg_advisory_lock retorna void (NULL); GET_LOCK retorna 0 no timeout
+	if got.Valid && got.Int64 != 1 {
+		conn.Close()
+		return nil, fmt.Errorf("timeout esperando o lock de migração (outra instância migrando?)")
+	}
+
+	return func() {
+		_, _ = conn.ExecContext(context.Background(), unlockSQL, args[0])
+		conn.Close()
+	}, nil
+}
+
+func (m *Migrator) lockTimeout() time.Duration {
+	if m.LockTimeout > 0 {
+		return m.LockTimeout
+	}
+	return DefaultLockTimeout
+}
+
+func (m *Migrator) out() io.Writer {
+	if m.Out != nil {
+		return m.Out
+	}
+	return os.Stdout
+}
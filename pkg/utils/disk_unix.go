@@ -0,0 +1,14 @@
+//go:build !windows
+
+package utils
+
+import "syscall"
+
+// FreeDiskSpace retorna quantos bytes estão livres (para usuário não-root) no volume do path
+func FreeDiskSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package utils
+
+import "golang.org/x/sys/windows"
+
+// FreeDiskSpace retorna quantos bytes estão livres (para o usuário atual) no volume do path
+func FreeDiskSpace(path string) (uint64, error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var freeForUser, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(pathPtr, &freeForUser, &total, &totalFree); err != nil {
+		return 0, err
+	}
+	return freeForUser, nil
+}
@@ -2,8 +2,10 @@ package utils
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // EmptyDirectory remove todos os arquivos e subpastas de um diretório,
@@ -31,4 +33,58 @@ func EmptyDirectory(dir string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// PurgeResult conta o que o PurgeOlderThan removeu (ou removeria, no dry-run)
+type PurgeResult struct {
+	Files int
+	Bytes int64
+}
+
+// PurgeOlderThan apaga os arquivos de dir (e subpastas) modificados antes de before.
+// Subpastas que ficarem vazias também saem; o diretório raiz fica.
+// Com dryRun só conta, sem apagar nada.
+func PurgeOlderThan(dir string, before time.Time, dryRun bool) (PurgeResult, error) {
+	var res PurgeResult
+	var dirs []string
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Pasta que não existe não tem nada para limpar
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			if path != dir {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.ModTime().Before(before) {
+			return nil
+		}
+		if !dryRun {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("falha ao deletar %s: %w", path, err)
+			}
+		}
+		res.Files++
+		res.Bytes += info.Size()
+		return nil
+	})
+	if err != nil || dryRun {
+		return res, err
+	}
+
+	// Da mais funda para a mais rasa: o Remove só apaga pasta vazia
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(dirs[i])
+	}
+	return res, nil
+}